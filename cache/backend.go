@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Общее хранилище значений кэша (например, Redis для нескольких экземпляров парсера)
+type Backend interface {
+	Get(key string) ([]byte, bool, error)                  // Значение и наличие его в хранилище
+	Set(key string, value []byte, ttl time.Duration) error // Запись значения на заданное время
+	Del(key string) error                                  // Удаление значения
+}
+
+// Общее хранилище с удалением значений по префиксу ключей (иначе удаляются только известные экземпляру значения)
+type PrefixBackend interface {
+	Backend
+	DelPrefix(prefix string) (int, error) // Удаление значений с ключами, начинающимися с префикса
+}
+
+// Значение в общем хранилище
+type backendValue[V any] struct {
+	Value   V         `json:"value"`
+	Created time.Time `json:"created,omitempty"`
+	Expires time.Time `json:"expires"`
+}
+
+// Общее хранилище значений (по умолчанию значения хранятся только в памяти)
+func WithBackend(backend Backend) Option {
+	return func(o *options) {
+		o.backend = backend
+	}
+}
+
+// Изменение общего хранилища значений (nil - значения только в памяти)
+func (c *Cache[K, V]) SetBackend(backend Backend) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.backend = backend
+}
+
+// Ключ значения в общем хранилище (с пространством имен)
+func (c *Cache[K, V]) backendKey(key K) string {
+	if c.namespace == "" {
+		return fmt.Sprint(key)
+	}
+
+	return c.namespace + ":" + fmt.Sprint(key)
+}
+
+// Префикс ключей в общем хранилище (с пространством имен)
+func (c *Cache[K, V]) backendPrefix(prefix string) string {
+	if c.namespace == "" {
+		return prefix
+	}
+
+	return c.namespace + ":" + prefix
+}
+
+// Запись значения в общее хранилище (на время хранения вместе с устаревшим значением)
+func (c *Cache[K, V]) store(backend Backend, staleTTL time.Duration, data cacheData[K, V]) {
+	ttl := time.Until(data.expires.Add(staleTTL))
+	if ttl <= 0 {
+		return
+	}
+
+	value, err := json.Marshal(backendValue[V]{Value: data.value, Created: data.created, Expires: data.expires})
+	if err == nil {
+		err = backend.Set(c.backendKey(data.key), value, ttl)
+	}
+
+	if err != nil {
+		c.backendError(err)
+	}
+}
+
+// Чтение значения из общего хранилища с записью в память
+func (c *Cache[K, V]) load(backend Backend, staleTTL time.Duration, key K) (cacheData[K, V], bool) {
+	value, ok, err := backend.Get(c.backendKey(key))
+	if err != nil {
+		c.backendError(err)
+	}
+	if !ok {
+		return cacheData[K, V]{}, false
+	}
+
+	var v backendValue[V]
+
+	if err := json.Unmarshal(value, &v); err != nil {
+		c.backendError(err)
+		return cacheData[K, V]{}, false
+	}
+
+	if time.Now().After(v.Expires.Add(staleTTL)) {
+		return cacheData[K, V]{}, false
+	}
+
+	data := cacheData[K, V]{key: key, value: v.Value, created: v.Created, expires: v.Expires}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if !c.isEnabled {
+		return cacheData[K, V]{}, false
+	}
+
+	// Значение в памяти могло обновиться за время запроса
+	if e, ok := c.values[key]; ok && !e.Value.(*cacheData[K, V]).expires.Before(data.expires) {
+		return *e.Value.(*cacheData[K, V]), true
+	}
+
+	c.put(data)
+
+	return data, true
+}
+
+// Ошибка общего хранилища (значение считается отсутствующим)
+func (c *Cache[K, V]) backendError(err error) {
+	log.Printf("Ошибка хранилища кэша %s: %v", c.namespace, err)
+}
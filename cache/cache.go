@@ -1,117 +1,448 @@
 package cache
 
 import (
-	"strings"
+	"container/list"
+	"sort"
 	"sync"
 	"time"
 )
 
-// Кэш
-type cache struct {
-	isEnabled bool
-	values    map[string]cacheData
-	success   int
-	failed    int
-	cancel    chan bool
-	m         sync.RWMutex
+// Кэш значений типа V по ключам типа K
+type Cache[K comparable, V any] struct {
+	namespace  string
+	isEnabled  bool
+	ttl        time.Duration
+	staleTTL   time.Duration
+	keyFunc    func(K) K
+	sizeFunc   func(V) int64
+	maxEntries int
+	maxBytes   int64
+	values     map[K]*list.Element
+	order      *list.List // Порядок использования (в начале - последние)
+	bytes      int64
+	success    int
+	failed     int
+	evicted    int
+	stops      []func()
+	backend    Backend // Общее хранилище (nil - значения только в памяти)
+	m          sync.RWMutex
 }
 
 // Данные кэша
-type cacheData struct {
-	value   interface{}
+type cacheData[K comparable, V any] struct {
+	key     K
+	value   V
+	created time.Time
 	expires time.Time
+	size    int64
 }
 
-// Объект кэша
-var c = cache{values: make(map[string]cacheData), cancel: make(chan bool)}
+// Параметры кэша
+type options struct {
+	namespace  string
+	isEnabled  bool
+	ttl        time.Duration
+	staleTTL   time.Duration
+	interval   time.Duration
+	keyFunc    any
+	sizeFunc   any
+	maxEntries int
+	maxBytes   int64
+	backend    Backend
+}
+
+// Параметр конструктора кэша
+type Option func(*options)
+
+// Пространство имен кэша (кэш с именем доступен через Namespaces)
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// Время хранения значений по умолчанию (для Set)
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// Время, в течение которого устаревшие значения еще можно получить (через GetStale)
+func WithStaleTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.staleTTL = ttl
+	}
+}
+
+// Включение или выключение кэша при создании (по умолчанию включен)
+func WithEnabled(isEnabled bool) Option {
+	return func(o *options) {
+		o.isEnabled = isEnabled
+	}
+}
+
+// Периодическая проверка кэша (очистка устаревших значений)
+func WithJanitor(interval time.Duration) Option {
+	return func(o *options) {
+		o.interval = interval
+	}
+}
+
+// Приведение ключей (например, к нижнему регистру)
+func WithKeyFunc[K comparable](fn func(K) K) Option {
+	return func(o *options) {
+		o.keyFunc = fn
+	}
+}
+
+// Предельное число значений (при превышении вытесняются давно не использованные)
+func WithMaxEntries(n int) Option {
+	return func(o *options) {
+		o.maxEntries = n
+	}
+}
+
+// Предельный примерный объем значений в байтах (размер значения - по функции)
+func WithMaxBytes[V any](n int64, size func(V) int64) Option {
+	return func(o *options) {
+		o.maxBytes = n
+		o.sizeFunc = size
+	}
+}
+
+// Конструктор кэша
+func New[K comparable, V any](opts ...Option) *Cache[K, V] {
+	o := options{isEnabled: true}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	c := &Cache[K, V]{
+		namespace:  o.namespace,
+		isEnabled:  o.isEnabled,
+		ttl:        o.ttl,
+		staleTTL:   o.staleTTL,
+		maxEntries: o.maxEntries,
+		backend:    o.backend,
+		values:     make(map[K]*list.Element),
+		order:      list.New(),
+	}
+
+	if fn, ok := o.keyFunc.(func(K) K); ok {
+		c.keyFunc = fn
+	}
+	if fn, ok := o.sizeFunc.(func(V) int64); ok {
+		c.sizeFunc = fn
+		c.maxBytes = o.maxBytes
+	}
+
+	if o.interval > 0 {
+		c.CheckEvery(o.interval)
+	}
+
+	if c.namespace != "" {
+		register(c)
+	}
+
+	return c
+}
+
+// Изменение времени хранения значений (для новых значений) и устаревших значений
+func (c *Cache[K, V]) SetTTL(ttl, staleTTL time.Duration) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.ttl = ttl
+	c.staleTTL = staleTTL
+}
+
+// Изменение пределов кэша (0 - без ограничения, объем - только с функцией размера)
+func (c *Cache[K, V]) SetLimits(maxEntries int, maxBytes int64) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.maxEntries = maxEntries
+	if c.sizeFunc != nil {
+		c.maxBytes = maxBytes
+	}
+
+	c.evict()
+}
+
+// Пространство имен кэша
+func (c *Cache[K, V]) Namespace() string {
+	return c.namespace
+}
 
 // Включение кэша
-func Enable() {
+func (c *Cache[K, V]) Enable() {
+	c.m.Lock()
+	defer c.m.Unlock()
+
 	c.isEnabled = true
 }
 
 // Выключение кэша
-func Disable() {
+func (c *Cache[K, V]) Disable() {
+	c.m.Lock()
+	defer c.m.Unlock()
+
 	c.isEnabled = false
 }
 
-// Запись значения кэша
-func SetValue(key string, value interface{}, expires time.Duration) bool {
+// Включен ли кэш
+func (c *Cache[K, V]) IsEnabled() bool {
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	return c.isEnabled
+}
+
+// Приведение ключа (пустой ключ не используется)
+func (c *Cache[K, V]) key(key K) (K, bool) {
+	if c.keyFunc != nil {
+		key = c.keyFunc(key)
+	}
+
+	var zero K
+
+	return key, key != zero
+}
+
+// Запись значения кэша на время по умолчанию
+func (c *Cache[K, V]) Set(key K, value V) bool {
+	c.m.RLock()
+	ttl := c.ttl
+	c.m.RUnlock()
+
+	return c.SetWithTTL(key, value, ttl)
+}
+
+// Запись значения кэша на заданное время
+func (c *Cache[K, V]) SetWithTTL(key K, value V, expires time.Duration) bool {
 	c.m.Lock()
-	defer c.m.Unlock()
 
-	if !c.isEnabled {
+	key, ok := c.key(key)
+	if !c.isEnabled || !ok {
+		c.m.Unlock()
 		return false
 	}
 
-	key = strings.ToLower(key)
+	now := time.Now()
+	data := cacheData[K, V]{
+		key:     key,
+		value:   value,
+		created: now,
+		expires: now.Add(expires),
+	}
+
+	ok = c.put(data)
+	backend, staleTTL := c.backend, c.staleTTL
+
+	c.m.Unlock()
+
+	// Запись в общее хранилище (без блокировки кэша на время запроса)
+	if ok && backend != nil {
+		c.store(backend, staleTTL, data)
+	}
+
+	return ok
+}
+
+// Запись значения кэша на время по умолчанию, если текущее значение в памяти принято функцией сравнения
+// (сравнение и запись выполняются под одной блокировкой)
+func (c *Cache[K, V]) CompareAndSet(key K, value V, compare func(old V, ok bool) bool) bool {
+	c.m.Lock()
 
-	if key == "" {
+	key, ok := c.key(key)
+	if !c.isEnabled || !ok {
+		c.m.Unlock()
 		return false
 	}
 
-	c.values[key] = cacheData{
+	old, ok := c.lookup(key)
+	if !compare(old.value, ok) {
+		c.m.Unlock()
+		return false
+	}
+
+	now := time.Now()
+	data := cacheData[K, V]{
+		key:     key,
 		value:   value,
-		expires: time.Now().Add(expires),
+		created: now,
+		expires: now.Add(c.ttl),
+	}
+
+	ok = c.put(data)
+	backend, staleTTL := c.backend, c.staleTTL
+
+	c.m.Unlock()
+
+	if ok && backend != nil {
+		c.store(backend, staleTTL, data)
+	}
+
+	return ok
+}
+
+// Запись значения в память (без блокировки)
+func (c *Cache[K, V]) put(data cacheData[K, V]) bool {
+	if c.sizeFunc != nil {
+		data.size = c.sizeFunc(data.value)
+	}
+
+	// Значение больше всего кэша не записывается
+	if c.maxBytes > 0 && data.size > c.maxBytes {
+		c.remove(data.key)
+		return false
 	}
 
+	c.remove(data.key)
+	c.values[data.key] = c.order.PushFront(&data)
+	c.bytes += data.size
+
+	c.evict()
+
 	return true
 }
 
+// Вытеснение давно не использованных значений сверх пределов
+func (c *Cache[K, V]) evict() {
+	for c.order.Len() > 0 && c.isOverflow() {
+		data := c.order.Back().Value.(*cacheData[K, V])
+		c.remove(data.key)
+		c.evicted++
+	}
+}
+
+// Превышены ли пределы кэша
+func (c *Cache[K, V]) isOverflow() bool {
+	return c.maxEntries > 0 && c.order.Len() > c.maxEntries || c.maxBytes > 0 && c.bytes > c.maxBytes
+}
+
+// Удаление значения (без блокировки)
+func (c *Cache[K, V]) remove(key K) {
+	e, ok := c.values[key]
+	if !ok {
+		return
+	}
+
+	c.bytes -= e.Value.(*cacheData[K, V]).size
+	c.order.Remove(e)
+	delete(c.values, key)
+}
+
 // Чтение значения кэша
-func Value(key string) (interface{}, bool) {
-	c.m.Lock()
-	defer c.m.Unlock()
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	value, isStale, ok := c.GetStale(key)
+	if isStale {
+		var zero V
+		return zero, false
+	}
+
+	return value, ok
+}
+
+// Чтение значения кэша, в том числе устаревшего (в пределах времени хранения устаревших значений)
+func (c *Cache[K, V]) GetStale(key K) (V, bool, bool) {
+	var value V
 
-	var value interface{}
+	c.m.Lock()
 
 	if !c.isEnabled {
-		return value, false
+		c.m.Unlock()
+		return value, false, false
 	}
 
-	key = strings.ToLower(key)
-	data, ok := c.values[key]
+	key, _ = c.key(key)
+	data, ok := c.lookup(key)
+	backend, staleTTL := c.backend, c.staleTTL
+
+	c.m.Unlock()
+
+	// Свежего значения в памяти нет - чтение из общего хранилища (там оно могло быть обновлено)
+	if backend != nil && (!ok || time.Now().After(data.expires)) {
+		if loaded, isLoaded := c.load(backend, staleTTL, key); isLoaded && (!ok || loaded.expires.After(data.expires)) {
+			data, ok = loaded, true
+		}
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
 
 	if !ok {
 		c.failed++
-		return value, false
+		return value, false, false
 	}
 
+	// Устаревшее значение - промах, хотя оно и возвращается
 	if time.Now().After(data.expires) {
 		c.failed++
-		delete(c.values, key)
-		return value, false
+		return data.value, true, true
 	}
 
 	c.success++
-	return data.value, true
+	return data.value, false, true
 }
 
-// Удаление значения кэша
-func Remove(key string) {
+// Поиск значения в памяти (без блокировки, значения старше времени хранения устаревших удаляются)
+func (c *Cache[K, V]) lookup(key K) (cacheData[K, V], bool) {
+	e, ok := c.values[key]
+	if !ok {
+		return cacheData[K, V]{}, false
+	}
+
+	data := e.Value.(*cacheData[K, V])
+
+	if time.Now().After(data.expires.Add(c.staleTTL)) {
+		c.remove(key)
+		return cacheData[K, V]{}, false
+	}
+
+	c.order.MoveToFront(e)
+
+	return *data, true
+}
+
+// Удаление значения кэша (в том числе из общего хранилища)
+func (c *Cache[K, V]) Remove(key K) {
 	c.m.Lock()
-	defer c.m.Unlock()
 
 	if !c.isEnabled {
+		c.m.Unlock()
 		return
 	}
 
-	key = strings.ToLower(key)
-	delete(c.values, key)
+	key, _ = c.key(key)
+	c.remove(key)
+	backend := c.backend
+
+	c.m.Unlock()
+
+	if backend != nil {
+		if err := backend.Del(c.backendKey(key)); err != nil {
+			c.backendError(err)
+		}
+	}
 }
 
-// Очистка кэша
-func Clear() {
+// Очистка кэша (только в памяти - общее хранилище не очищается)
+func (c *Cache[K, V]) Clear() {
 	c.m.Lock()
 	defer c.m.Unlock()
 
-	c.values = make(map[string]cacheData)
+	c.values = make(map[K]*list.Element)
+	c.order.Init()
+	c.bytes = 0
 	c.success = 0
 	c.failed = 0
+	c.evicted = 0
 }
 
 // Проверка кэша
-func Check() {
+func (c *Cache[K, V]) Check() {
 	c.m.Lock()
 	defer c.m.Unlock()
 
@@ -119,35 +450,141 @@ func Check() {
 		return
 	}
 
-	for key, data := range c.values {
-		if time.Now().After(data.expires) {
-			delete(c.values, key)
+	for key, e := range c.values {
+		if time.Now().After(e.Value.(*cacheData[K, V]).expires.Add(c.staleTTL)) {
+			c.remove(key)
 		}
 	}
 }
 
-// Периодическая проверка кэша
-func CheckEvery(interval time.Duration) func() {
+// Периодическая проверка кэша (функцию остановки можно вызывать несколько раз)
+func (c *Cache[K, V]) CheckEvery(interval time.Duration) func() {
+	return c.every(interval, c.Check)
+}
+
+// Периодическое выполнение функции (до остановки кэша)
+func (c *Cache[K, V]) every(interval time.Duration, fn func()) func() {
+	var (
+		done    = make(chan struct{})
+		stopped = make(chan struct{})
+		once    sync.Once
+	)
+
 	go func() {
+		defer close(stopped)
+
 		for {
 			select {
 			case <-time.After(interval):
-				Check()
-			case <-c.cancel:
+				fn()
+			case <-done:
 				return
 			}
 		}
 	}()
 
-	return func() {
-		c.cancel <- true
+	// После остановки вызовов больше нет
+	stop := func() {
+		once.Do(func() { close(done) })
+		<-stopped
 	}
+
+	c.m.Lock()
+	c.stops = append(c.stops, stop)
+	c.m.Unlock()
+
+	return stop
 }
 
-// Статистика кэша
-func Stats() (int, int, int) {
+// Остановка всех периодических проверок и сохранений кэша
+func (c *Cache[K, V]) Stop() {
+	c.m.Lock()
+	stops := c.stops
+	c.stops = nil
+	c.m.Unlock()
+
+	for _, stop := range stops {
+		stop()
+	}
+}
+
+// Число значений кэша
+func (c *Cache[K, V]) Len() int {
 	c.m.RLock()
 	defer c.m.RUnlock()
 
-	return len(c.values), c.success, c.failed
+	return len(c.values)
+}
+
+// Примерный объем значений кэша в байтах
+func (c *Cache[K, V]) Bytes() int64 {
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	return c.bytes
+}
+
+// Статистика кэша (число значений, попаданий, промахов и вытесненных значений)
+func (c *Cache[K, V]) Stats() (int, int, int, int) {
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	return len(c.values), c.success, c.failed, c.evicted
+}
+
+// Кэш с пространством имен (независимо от типов ключей и значений)
+type Namespace interface {
+	Namespace() string
+	IsEnabled() bool
+	Stats() (int, int, int, int)
+	Bytes() int64
+	Entries(prefix string) []Entry
+	Peek(key string) (any, Entry, bool)
+	Purge(key string) bool
+	PurgePrefix(prefix string) int
+	Clear()
+}
+
+// Кэши с пространствами имен
+type registry struct {
+	caches map[string]Namespace
+	m      sync.RWMutex
+}
+
+// Объект кэшей с пространствами имен
+var r = registry{caches: make(map[string]Namespace)}
+
+// Регистрация кэша (кэш с тем же пространством имен заменяется)
+func register(c Namespace) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.caches[c.Namespace()] = c
+}
+
+// Получение кэша по пространству имен
+func Lookup(namespace string) (Namespace, bool) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	c, ok := r.caches[namespace]
+
+	return c, ok
+}
+
+// Кэши с пространствами имен (по порядку имен)
+func Namespaces() []Namespace {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	list := make([]Namespace, 0, len(r.caches))
+	for _, c := range r.caches {
+		list = append(list, c)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Namespace() < list[j].Namespace()
+	})
+
+	return list
 }
@@ -2,12 +2,18 @@ package cache
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
 
+// Кэш для тестов (как прежний общий кэш: ключи-строки без учета регистра)
+func newTestCache(opts ...Option) *Cache[string, interface{}] {
+	return New[string, interface{}](append([]Option{WithKeyFunc(strings.ToLower)}, opts...)...)
+}
+
 func TestEnable(t *testing.T) {
-	Disable()
+	c := newTestCache(WithEnabled(false))
 
 	tests := []struct {
 		test     string
@@ -21,10 +27,10 @@ func TestEnable(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.test, func(t *testing.T) {
 			if tt.isEnable {
-				Enable()
+				c.Enable()
 			}
 
-			result := c.isEnabled
+			result := c.IsEnabled()
 
 			if result != tt.result {
 				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
@@ -34,7 +40,7 @@ func TestEnable(t *testing.T) {
 }
 
 func TestDisable(t *testing.T) {
-	Enable()
+	c := newTestCache()
 
 	tests := []struct {
 		test      string
@@ -48,10 +54,10 @@ func TestDisable(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.test, func(t *testing.T) {
 			if tt.isDisable {
-				Disable()
+				c.Disable()
 			}
 
-			result := c.isEnabled
+			result := c.IsEnabled()
 
 			if result != tt.result {
 				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
@@ -61,8 +67,7 @@ func TestDisable(t *testing.T) {
 }
 
 func TestSetValue(t *testing.T) {
-	Enable()
-	Clear()
+	c := newTestCache()
 
 	tests := []struct {
 		test      string
@@ -80,10 +85,10 @@ func TestSetValue(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.test, func(t *testing.T) {
 			if tt.isDisable {
-				Disable()
+				c.Disable()
 			}
 
-			result := SetValue(tt.key, tt.value, tt.expires)
+			result := c.SetWithTTL(tt.key, tt.value, tt.expires)
 
 			if result != tt.result {
 				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
@@ -93,11 +98,11 @@ func TestSetValue(t *testing.T) {
 }
 
 func TestValue(t *testing.T) {
-	Enable()
+	c := newTestCache()
 
-	SetValue("str", "value", time.Second)
-	SetValue("INT", 1000, 5*time.Second)
-	SetValue("Expired", []string{}, 0)
+	c.SetWithTTL("str", "value", time.Second)
+	c.SetWithTTL("INT", 1000, 5*time.Second)
+	c.SetWithTTL("Expired", []string{}, 0)
 
 	tests := []struct {
 		test      string
@@ -118,10 +123,10 @@ func TestValue(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.test, func(t *testing.T) {
 			if tt.isDisable {
-				Disable()
+				c.Disable()
 			}
 
-			result, isExist := Value(tt.key)
+			result, isExist := c.Get(tt.key)
 
 			if isExist != tt.isExist {
 				t.Fatalf("Получен результат: %v, ожидается: %v", isExist, tt.isExist)
@@ -134,12 +139,11 @@ func TestValue(t *testing.T) {
 }
 
 func TestRemove(t *testing.T) {
-	Enable()
-	Clear()
+	c := newTestCache()
 
-	SetValue("str", "value", time.Second)
-	SetValue("INT", 1000, 5*time.Second)
-	SetValue("Expired", []string{}, 0)
+	c.SetWithTTL("str", "value", time.Second)
+	c.SetWithTTL("INT", 1000, 5*time.Second)
+	c.SetWithTTL("Expired", []string{}, 0)
 
 	tests := []struct {
 		test      string
@@ -156,11 +160,11 @@ func TestRemove(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.test, func(t *testing.T) {
 			if tt.isDisable {
-				Disable()
+				c.Disable()
 			}
 
-			Remove(tt.key)
-			result := len(c.values)
+			c.Remove(tt.key)
+			result := c.Len()
 
 			if result != tt.result {
 				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
@@ -170,13 +174,12 @@ func TestRemove(t *testing.T) {
 }
 
 func TestClear(t *testing.T) {
-	Enable()
-	Clear()
+	c := newTestCache()
 
-	SetValue("Key", "Value", 5*time.Millisecond)
+	c.SetWithTTL("Key", "Value", 5*time.Millisecond)
 
-	Value("Key")
-	Value("None")
+	c.Get("Key")
+	c.Get("None")
 
 	tests := []struct {
 		test    string
@@ -192,7 +195,7 @@ func TestClear(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.test, func(t *testing.T) {
 			if tt.isClear {
-				Clear()
+				c.Clear()
 			}
 
 			count, success, failed := len(c.values), c.success, c.failed
@@ -211,12 +214,11 @@ func TestClear(t *testing.T) {
 }
 
 func TestCheck(t *testing.T) {
-	Enable()
-	Clear()
+	c := newTestCache()
 
-	SetValue("str", "value", 50*time.Millisecond)
-	SetValue("INT", 1000, 100*time.Millisecond)
-	SetValue("Expired", []string{}, 0)
+	c.SetWithTTL("str", "value", 50*time.Millisecond)
+	c.SetWithTTL("INT", 1000, 100*time.Millisecond)
+	c.SetWithTTL("Expired", []string{}, 0)
 
 	tests := []struct {
 		test      string
@@ -233,13 +235,13 @@ func TestCheck(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.test, func(t *testing.T) {
 			if tt.isDisable {
-				Disable()
+				c.Disable()
 			}
 
 			time.Sleep(time.Duration(tt.waitMs) * time.Millisecond)
 
-			Check()
-			result := len(c.values)
+			c.Check()
+			result := c.Len()
 
 			if result != tt.result {
 				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
@@ -249,16 +251,15 @@ func TestCheck(t *testing.T) {
 }
 
 func TestCheckEvery(t *testing.T) {
-	Enable()
-	Clear()
+	c := newTestCache()
 
-	SetValue("str", "value", 50*time.Millisecond)
-	SetValue("INT", 1000, 100*time.Millisecond)
-	SetValue("Expired", []string{}, 0)
+	c.SetWithTTL("str", "value", 50*time.Millisecond)
+	c.SetWithTTL("INT", 1000, 100*time.Millisecond)
+	c.SetWithTTL("Expired", []string{}, 0)
 
 	interval := 30 * time.Millisecond
 
-	cancel := CheckEvery(interval)
+	cancel := c.CheckEvery(interval)
 
 	tests := []struct {
 		test     string
@@ -278,7 +279,7 @@ func TestCheckEvery(t *testing.T) {
 				cancel()
 			}
 
-			result := len(c.values)
+			result := c.Len()
 
 			if result != tt.result {
 				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
@@ -290,13 +291,12 @@ func TestCheckEvery(t *testing.T) {
 }
 
 func TestStats(t *testing.T) {
-	Enable()
-	Clear()
+	c := newTestCache()
 
-	SetValue("Key", "Value", 5*time.Millisecond)
+	c.SetWithTTL("Key", "Value", 5*time.Millisecond)
 
-	Value("Key")
-	Value("None")
+	c.Get("Key")
+	c.Get("None")
 
 	tests := []struct {
 		test    string
@@ -313,8 +313,8 @@ func TestStats(t *testing.T) {
 		t.Run(tt.test, func(t *testing.T) {
 			time.Sleep(time.Duration(tt.waitMs) * time.Millisecond)
 
-			Check()
-			count, success, failed := Stats()
+			c.Check()
+			count, success, failed, _ := c.Stats()
 
 			if count != tt.count {
 				t.Errorf("Count - получено значение: %v, ожидается: %v", count, tt.count)
@@ -328,3 +328,322 @@ func TestStats(t *testing.T) {
 		})
 	}
 }
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		test      string
+		opts      []Option
+		key       string
+		isEnabled bool
+		isExist   bool
+	}{
+		{"Default", nil, "Key", true, false},
+		{"KeyFunc", []Option{WithKeyFunc(strings.ToLower)}, "Key", true, true},
+		{"WrongKeyFunc", []Option{WithKeyFunc(func(k int) int { return k })}, "Key", true, false},
+		{"TTL", []Option{WithTTL(time.Second)}, "key", true, true},
+		{"Disabled", []Option{WithEnabled(false)}, "key", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			c := New[string, int](tt.opts...)
+			c.SetWithTTL("key", 1, time.Second)
+
+			if c.IsEnabled() != tt.isEnabled {
+				t.Errorf("Получено значение: %v, ожидается: %v", c.IsEnabled(), tt.isEnabled)
+			}
+			if _, isExist := c.Get(tt.key); isExist != tt.isExist {
+				t.Errorf("Получен результат: %v, ожидается: %v", isExist, tt.isExist)
+			}
+		})
+	}
+
+	// Время хранения по умолчанию
+	c := New[int, string](WithTTL(10 * time.Millisecond))
+	c.Set(1, "value")
+
+	if value, ok := c.Get(1); !ok || value != "value" {
+		t.Errorf("Получено значение: %q (%v), ожидается: %q", value, ok, "value")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := c.Get(1); ok {
+		t.Errorf("Значение не устарело")
+	}
+
+	// Нулевой ключ не используется
+	if c.Set(0, "zero") {
+		t.Errorf("Записано значение по нулевому ключу")
+	}
+}
+
+func TestStop(t *testing.T) {
+	c := New[string, int](WithJanitor(10 * time.Millisecond))
+	stop := c.CheckEvery(10 * time.Millisecond)
+
+	c.SetWithTTL("expired", 1, 0)
+	c.SetWithTTL("actual", 2, time.Minute)
+
+	time.Sleep(30 * time.Millisecond)
+
+	if result := c.Len(); result != 1 {
+		t.Errorf("Получено значение: %v, ожидается: %v", result, 1)
+	}
+
+	// Повторная остановка не блокируется
+	done := make(chan struct{})
+
+	go func() {
+		stop()
+		stop()
+		c.Stop()
+		c.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Остановка заблокирована")
+	}
+
+	c.SetWithTTL("expired", 1, 0)
+	time.Sleep(30 * time.Millisecond)
+
+	if result := c.Len(); result != 2 {
+		t.Errorf("Получено значение: %v, ожидается: %v (проверка остановлена)", result, 2)
+	}
+}
+
+func TestNamespaces(t *testing.T) {
+	first := New[string, int](WithNamespace("test_first"))
+	second := New[int, []string](WithNamespace("test_second"))
+	New[string, int]()
+
+	first.Set("key", 1)
+	second.Set(1, []string{"value"})
+
+	tests := []struct {
+		test      string
+		namespace string
+		isExist   bool
+		count     int
+	}{
+		{"First", "test_first", true, 1},
+		{"Second", "test_second", true, 1},
+		{"None", "test_none", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			c, ok := Lookup(tt.namespace)
+
+			if ok != tt.isExist {
+				t.Fatalf("Получен результат: %v, ожидается: %v", ok, tt.isExist)
+			}
+			if !ok {
+				return
+			}
+
+			if count, _, _, _ := c.Stats(); count != tt.count {
+				t.Errorf("Получено значение: %v, ожидается: %v", count, tt.count)
+			}
+		})
+	}
+
+	// Очистка через пространство имен очищает сам кэш
+	if c, ok := Lookup("test_second"); ok {
+		c.Clear()
+	}
+	if second.Len() != 0 {
+		t.Errorf("Кэш не очищен")
+	}
+
+	var names []string
+	for _, c := range Namespaces() {
+		if strings.HasPrefix(c.Namespace(), "test_") {
+			names = append(names, c.Namespace())
+		}
+	}
+
+	if !reflect.DeepEqual(names, []string{"test_first", "test_second"}) {
+		t.Errorf("Получено значение: %v", names)
+	}
+}
+
+func TestEvict(t *testing.T) {
+	size := func(v string) int64 { return int64(len(v)) }
+
+	tests := []struct {
+		test    string
+		opts    []Option
+		values  []string // Ключ и значение совпадают, "+" в начале - чтение
+		keys    []string
+		bytes   int64
+		evicted int
+	}{
+		{"NoLimits", nil, []string{"a", "b", "c"}, []string{"a", "b", "c"}, 0, 0},
+		{"MaxEntries", []Option{WithMaxEntries(2)}, []string{"a", "b", "c"}, []string{"b", "c"}, 0, 1},
+		{"MaxEntriesLRU", []Option{WithMaxEntries(2)}, []string{"a", "b", "+a", "c"}, []string{"a", "c"}, 0, 1},
+		{"Update", []Option{WithMaxEntries(2)}, []string{"a", "b", "a", "c"}, []string{"a", "c"}, 0, 1},
+		{"MaxBytes", []Option{WithMaxBytes(6, size)}, []string{"aa", "bbb", "cccc"}, []string{"cccc"}, 4, 2},
+		{"MaxBytesLRU", []Option{WithMaxBytes(6, size)}, []string{"aa", "bb", "+aa", "ccc"}, []string{"aa", "ccc"}, 5, 1},
+		{"TooLarge", []Option{WithMaxBytes(3, size)}, []string{"aa", "bbbb"}, []string{"aa"}, 2, 0},
+		{"Both", []Option{WithMaxEntries(3), WithMaxBytes(100, size)}, []string{"a", "b", "c", "d"}, []string{"b", "c", "d"}, 3, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			c := New[string, string](tt.opts...)
+
+			for _, value := range tt.values {
+				if key, ok := strings.CutPrefix(value, "+"); ok {
+					c.Get(key)
+					continue
+				}
+
+				c.SetWithTTL(value, value, time.Minute)
+			}
+
+			var keys []string
+			for _, key := range []string{"a", "b", "c", "d", "aa", "bb", "bbb", "ccc", "cccc", "bbbb"} {
+				if _, ok := c.values[key]; ok {
+					keys = append(keys, key)
+				}
+			}
+
+			if !reflect.DeepEqual(keys, tt.keys) {
+				t.Errorf("Получено значение: %v, ожидается: %v", keys, tt.keys)
+			}
+			if result := c.Bytes(); result != tt.bytes {
+				t.Errorf("Bytes - получено значение: %v, ожидается: %v", result, tt.bytes)
+			}
+			if _, _, _, evicted := c.Stats(); evicted != tt.evicted {
+				t.Errorf("Evicted - получено значение: %v, ожидается: %v", evicted, tt.evicted)
+			}
+		})
+	}
+
+	// Учет объема при удалении и очистке
+	c := New[string, string](WithMaxBytes(100, size))
+	c.SetWithTTL("a", "aaa", time.Minute)
+	c.SetWithTTL("b", "bb", time.Minute)
+	c.Remove("a")
+
+	if result := c.Bytes(); result != 2 {
+		t.Errorf("Получено значение: %v, ожидается: %v", result, 2)
+	}
+
+	// Новые пределы применяются сразу
+	c.SetWithTTL("c", "cc", time.Minute)
+	c.SetLimits(1, 100)
+
+	if _, ok := c.values["c"]; !ok || c.Len() != 1 {
+		t.Errorf("Получено значение: %v, ожидается: %v", c.Len(), 1)
+	}
+
+	c.Clear()
+
+	if result := c.Bytes(); result != 0 || c.order.Len() != 0 {
+		t.Errorf("Получено значение: %v, ожидается: %v", result, 0)
+	}
+}
+
+func TestGetStale(t *testing.T) {
+	c := New[string, string](WithStaleTTL(50 * time.Millisecond))
+
+	c.SetWithTTL("fresh", "fresh", time.Minute)
+	c.SetWithTTL("stale", "stale", 0)
+	c.SetWithTTL("expired", "expired", -time.Minute)
+
+	tests := []struct {
+		test    string
+		key     string
+		result  string
+		isStale bool
+		isExist bool
+		isGet   bool // Значение возвращается и через Get
+	}{
+		{"Fresh", "fresh", "fresh", false, true, true},
+		{"Stale", "stale", "stale", true, true, false},
+		{"Expired", "expired", "", false, false, false},
+		{"None", "none", "", false, false, false},
+	}
+
+	time.Sleep(time.Millisecond)
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			result, isStale, isExist := c.GetStale(tt.key)
+
+			if result != tt.result || isStale != tt.isStale || isExist != tt.isExist {
+				t.Errorf("Получено значение: %q (%v, %v), ожидается: %q (%v, %v)", result, isStale, isExist, tt.result, tt.isStale, tt.isExist)
+			}
+			if _, isGet := c.Get(tt.key); isGet != tt.isGet {
+				t.Errorf("Get - получен результат: %v, ожидается: %v", isGet, tt.isGet)
+			}
+		})
+	}
+
+	// Проверка удаляет только значения старше времени хранения устаревших
+	c.Check()
+	if result := c.Len(); result != 2 {
+		t.Errorf("Получено значение: %v, ожидается: %v", result, 2)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	c.Check()
+
+	if result := c.Len(); result != 1 {
+		t.Errorf("Получено значение: %v, ожидается: %v", result, 1)
+	}
+
+	// Новое время хранения
+	c.SetTTL(time.Millisecond, 0)
+	c.Set("new", "new")
+	time.Sleep(5 * time.Millisecond)
+
+	if _, _, ok := c.GetStale("new"); ok {
+		t.Errorf("Значение не устарело")
+	}
+}
+
+func TestCompareAndSet(t *testing.T) {
+	c := New[string, int](WithTTL(time.Minute))
+	c.Set("key", 1)
+
+	// Запись, только если текущее значение не изменилось
+	isCurrent := func(current int) func(int, bool) bool {
+		return func(old int, ok bool) bool {
+			return !ok || old == current
+		}
+	}
+
+	tests := []struct {
+		test    string
+		key     string
+		value   int
+		current int
+		isSet   bool
+		result  int
+	}{
+		{"Equal", "key", 2, 1, true, 2},
+		{"Changed", "key", 3, 1, false, 2},
+		{"Missing", "new", 4, 0, true, 4},
+		{"Empty", "", 5, 0, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			isSet := c.CompareAndSet(tt.key, tt.value, isCurrent(tt.current))
+
+			if isSet != tt.isSet {
+				t.Errorf("Получен результат: %v, ожидается: %v", isSet, tt.isSet)
+			}
+			if result, _ := c.Get(tt.key); result != tt.result {
+				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Описание значения кэша (для администрирования)
+type Entry struct {
+	Key     string    `json:"key"`
+	Created time.Time `json:"created,omitempty"` // Время записи
+	Expires time.Time `json:"expires"`
+	Age     int       `json:"age"` // Возраст в секундах
+	TTL     int       `json:"ttl"` // Оставшееся время хранения в секундах (отрицательное - значение устарело)
+	Size    int64     `json:"size,omitempty"`
+	IsStale bool      `json:"isStale"`
+}
+
+// Описание значения (без блокировки)
+func (c *Cache[K, V]) entryOf(data *cacheData[K, V], now time.Time) Entry {
+	e := Entry{
+		Key:     fmt.Sprint(data.key),
+		Created: data.created,
+		Expires: data.expires,
+		TTL:     int(data.expires.Sub(now).Seconds()),
+		Size:    data.size,
+		IsStale: now.After(data.expires),
+	}
+	if !data.created.IsZero() {
+		e.Age = int(now.Sub(data.created).Seconds())
+	}
+
+	return e
+}
+
+// Описания значений с ключами, начинающимися с префикса (от последних использованных)
+func (c *Cache[K, V]) Entries(prefix string) []Entry {
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	var (
+		entries = make([]Entry, 0, c.order.Len())
+		now     = time.Now()
+	)
+
+	prefix = c.prefix(prefix)
+
+	for e := c.order.Front(); e != nil; e = e.Next() {
+		data := e.Value.(*cacheData[K, V])
+
+		if now.After(data.expires.Add(c.staleTTL)) {
+			continue
+		}
+		if entry := c.entryOf(data, now); strings.HasPrefix(entry.Key, prefix) {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// Значение и его описание по ключу в виде строки (без учета в статистике и порядке использования)
+func (c *Cache[K, V]) Peek(key string) (any, Entry, bool) {
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	k, ok := c.find(key)
+	if !ok {
+		return nil, Entry{}, false
+	}
+
+	data := c.values[k].Value.(*cacheData[K, V])
+	now := time.Now()
+
+	if now.After(data.expires.Add(c.staleTTL)) {
+		return nil, Entry{}, false
+	}
+
+	return data.value, c.entryOf(data, now), true
+}
+
+// Удаление значения по ключу в виде строки (в том числе из общего хранилища)
+func (c *Cache[K, V]) Purge(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	c.m.Lock()
+
+	k, ok := c.find(key)
+	if ok {
+		c.remove(k)
+	}
+	backend := c.backend
+
+	c.m.Unlock()
+
+	// Значения может не быть в памяти, но быть в общем хранилище (если ключи - строки)
+	if _, isString := any(key).(K); backend != nil && (ok || isString) {
+		if err := backend.Del(c.backendKey(k)); err != nil {
+			c.backendError(err)
+		}
+	}
+
+	return ok
+}
+
+// Удаление значений с ключами, начинающимися с префикса (пустой префикс - все значения, статистика сохраняется)
+func (c *Cache[K, V]) PurgePrefix(prefix string) int {
+	c.m.Lock()
+
+	var keys []K
+
+	prefix = c.prefix(prefix)
+
+	for key := range c.values {
+		if strings.HasPrefix(fmt.Sprint(key), prefix) {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		c.remove(key)
+	}
+	backend := c.backend
+
+	c.m.Unlock()
+
+	if backend == nil {
+		return len(keys)
+	}
+
+	// Из общего хранилища значения удаляются по префиксу, в том числе неизвестные этому экземпляру
+	// (пустой префикс без пространства имен - только известные, чтобы не очистить чужие значения)
+	if pb, ok := backend.(PrefixBackend); ok && c.backendPrefix(prefix) != "" {
+		n, err := pb.DelPrefix(c.backendPrefix(prefix))
+		if err == nil && n < len(keys) {
+			return len(keys)
+		}
+		if err == nil {
+			return n
+		}
+
+		c.backendError(err)
+	}
+
+	for _, key := range keys {
+		if err := backend.Del(c.backendKey(key)); err != nil {
+			c.backendError(err)
+		}
+	}
+
+	return len(keys)
+}
+
+// Ключ значения в памяти по ключу в виде строки (для строковых ключей - приведенный, даже если значения нет)
+func (c *Cache[K, V]) find(key string) (K, bool) {
+	if k, ok := any(key).(K); ok {
+		k, _ = c.key(k)
+		_, ok = c.values[k]
+
+		return k, ok
+	}
+
+	for k := range c.values {
+		if fmt.Sprint(k) == key {
+			return k, true
+		}
+	}
+
+	var zero K
+
+	return zero, false
+}
+
+// Приведение префикса ключей (для строковых ключей)
+func (c *Cache[K, V]) prefix(prefix string) string {
+	if k, ok := any(prefix).(K); ok && prefix != "" && c.keyFunc != nil {
+		return fmt.Sprint(c.keyFunc(k))
+	}
+
+	return prefix
+}
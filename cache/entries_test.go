@@ -0,0 +1,162 @@
+package cache
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Ключи описаний значений
+func keysOf(entries []Entry) []string {
+	keys := make([]string, 0, len(entries))
+	for _, e := range entries {
+		keys = append(keys, e.Key)
+	}
+	return keys
+}
+
+func TestEntries(t *testing.T) {
+	c := New[string, string](WithKeyFunc(strings.ToLower), WithStaleTTL(time.Minute), WithMaxBytes(100, func(v string) int64 { return int64(len(v)) }))
+
+	c.SetWithTTL("https://t.me/first", "first", time.Hour)
+	c.SetWithTTL("https://t.me/second", "second", -time.Second)
+	c.SetWithTTL("https://t.me/expired", "expired", -time.Hour)
+	c.SetWithTTL("other", "other", time.Hour)
+
+	tests := []struct {
+		test   string
+		prefix string
+		result []string
+	}{
+		{"All", "", []string{"other", "https://t.me/second", "https://t.me/first"}},
+		{"Prefix", "https://t.me/", []string{"https://t.me/second", "https://t.me/first"}},
+		{"Case", "HTTPS://T.ME/F", []string{"https://t.me/first"}},
+		{"None", "none", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			result := keysOf(c.Entries(tt.prefix))
+
+			if !reflect.DeepEqual(result, tt.result) {
+				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
+			}
+		})
+	}
+
+	entries := c.Entries("https://t.me/")
+
+	if second := entries[0]; !second.IsStale || second.TTL > -1 || second.Size != 6 || second.Created.IsZero() {
+		t.Errorf("Получено значение: %+v", second)
+	}
+	if first := entries[1]; first.IsStale || first.TTL < 3590 || first.Age != 0 {
+		t.Errorf("Получено значение: %+v", first)
+	}
+}
+
+func TestPeek(t *testing.T) {
+	byString := New[string, string](WithKeyFunc(strings.ToLower), WithTTL(time.Hour))
+	byString.Set("Key", "value")
+
+	byInt := New[int, string](WithTTL(time.Hour))
+	byInt.Set(42, "answer")
+
+	tests := []struct {
+		test   string
+		cache  Namespace
+		key    string
+		result any
+		ok     bool
+	}{
+		{"String", byString, "key", "value", true},
+		{"StringCase", byString, "KEY", "value", true},
+		{"StringMissing", byString, "missing", nil, false},
+		{"Int", byInt, "42", "answer", true},
+		{"IntMissing", byInt, "7", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			result, entry, ok := tt.cache.Peek(tt.key)
+
+			if ok != tt.ok || result != tt.result {
+				t.Errorf("Получено значение: %v (%v), ожидается: %v (%v)", result, ok, tt.result, tt.ok)
+			}
+			if ok && entry.Key != strings.ToLower(tt.key) {
+				t.Errorf("Получен ключ: %v", entry.Key)
+			}
+		})
+	}
+
+	// Просмотр не учитывается в статистике
+	if _, success, failed, _ := byString.Stats(); success != 0 || failed != 0 {
+		t.Errorf("Получена статистика: %v, %v", success, failed)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	address, _ := newTestRedis(t, "")
+	r, _ := NewRedis(address)
+	defer r.Close()
+
+	c := New[string, string](WithKeyFunc(strings.ToLower), WithBackend(r), WithTTL(time.Hour))
+	other := New[string, string](WithBackend(r), WithTTL(time.Hour))
+
+	c.Set("https://t.me/first", "first")
+	c.Set("https://t.me/second", "second")
+	c.Set("other", "other")
+	c.Get("other")
+
+	// Значения только в общем хранилище
+	other.Set("shared", "shared")
+	other.Set("https://t.me/third", "third")
+
+	tests := []struct {
+		test   string
+		key    string
+		prefix string
+		result int
+		keys   []string
+	}{
+		{"Key", "HTTPS://T.ME/FIRST", "", 1, []string{"other", "https://t.me/second"}},
+		{"Missing", "missing", "", 0, []string{"other", "https://t.me/second"}},
+		{"Shared", "shared", "", 0, []string{"other", "https://t.me/second"}},
+		{"Prefix", "", "https://t.me/", 2, []string{"other"}},
+		{"All", "", "", 1, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			var result int
+
+			switch {
+			case tt.key != "":
+				if c.Purge(tt.key) {
+					result = 1
+				}
+			default:
+				result = c.PurgePrefix(tt.prefix)
+			}
+
+			if result != tt.result {
+				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
+			}
+			if keys := keysOf(c.Entries("")); !reflect.DeepEqual(keys, tt.keys) {
+				t.Errorf("Получено значение: %v, ожидается: %v", keys, tt.keys)
+			}
+		})
+	}
+
+	// Удаленные значения удалены и из общего хранилища
+	for _, key := range []string{"https://t.me/first", "https://t.me/second", "https://t.me/third", "shared"} {
+		if _, ok, _ := r.Get(key); ok {
+			t.Errorf("Значение не удалено из хранилища: %v", key)
+		}
+	}
+
+	// Статистика сохраняется
+	if _, success, _, _ := c.Stats(); success != 1 {
+		t.Errorf("Получена статистика: %v", success)
+	}
+}
@@ -0,0 +1,319 @@
+package cache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Число соединений, которые остаются открытыми между запросами
+const redisIdleConns = 8
+
+// Экранирование специальных символов шаблона ключей
+var redisPattern = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
+// Хранилище значений кэша в Redis (по протоколу RESP)
+type Redis struct {
+	address  string
+	password string
+	db       int
+	timeout  time.Duration
+	conns    chan *redisConn
+}
+
+// Соединение с Redis
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// Ошибка, которую вернул сервер Redis
+type RedisError string
+
+func (e RedisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// Конструктор хранилища в Redis (адрес - host:port или redis://[:password@]host:port[/db])
+func NewRedis(address string) (*Redis, error) {
+	r := &Redis{
+		address: address,
+		timeout: 5 * time.Second,
+		conns:   make(chan *redisConn, redisIdleConns),
+	}
+
+	if strings.Contains(address, "://") {
+		u, err := url.Parse(address)
+		if err != nil {
+			return nil, err
+		}
+		if u.Scheme != "redis" || u.Host == "" {
+			return nil, fmt.Errorf("адрес Redis невалиден: %s", address)
+		}
+
+		r.address = u.Host
+		r.password, _ = u.User.Password()
+
+		if db := strings.Trim(u.Path, "/"); db != "" {
+			if r.db, err = strconv.Atoi(db); err != nil {
+				return nil, fmt.Errorf("номер базы Redis невалиден: %s", db)
+			}
+		}
+	}
+
+	if _, _, err := net.SplitHostPort(r.address); err != nil {
+		return nil, fmt.Errorf("адрес Redis невалиден: %w", err)
+	}
+
+	return r, nil
+}
+
+// Чтение значения
+func (r *Redis) Get(key string) ([]byte, bool, error) {
+	reply, err := r.do("GET", key)
+	if err != nil || reply == nil {
+		return nil, false, err
+	}
+
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: неожиданный ответ на GET: %v", reply)
+	}
+
+	return value, true, nil
+}
+
+// Запись значения на заданное время (с точностью до миллисекунд)
+func (r *Redis) Set(key string, value []byte, ttl time.Duration) error {
+	ms := ttl.Milliseconds()
+	if ms <= 0 {
+		ms = 1
+	}
+
+	_, err := r.do("SET", key, string(value), "PX", strconv.FormatInt(ms, 10))
+
+	return err
+}
+
+// Удаление значения
+func (r *Redis) Del(key string) error {
+	_, err := r.do("DEL", key)
+
+	return err
+}
+
+// Удаление значений с ключами, начинающимися с префикса (SCAN по шаблону и DEL найденных ключей)
+func (r *Redis) DelPrefix(prefix string) (int, error) {
+	var (
+		n       int
+		cursor  = "0"
+		pattern = redisPattern.Replace(prefix) + "*"
+	)
+
+	for {
+		reply, err := r.do("SCAN", cursor, "MATCH", pattern, "COUNT", "100")
+		if err != nil {
+			return n, err
+		}
+
+		values, ok := reply.([]any)
+		if !ok || len(values) != 2 {
+			return n, fmt.Errorf("redis: неожиданный ответ на SCAN: %v", reply)
+		}
+		next, isCursor := values[0].([]byte)
+		found, isKeys := values[1].([]any)
+		if !isCursor || !isKeys {
+			return n, fmt.Errorf("redis: неожиданный ответ на SCAN: %v", reply)
+		}
+
+		// Ключи могут повторяться между страницами - число удаленных считает сам DEL
+		if len(found) > 0 {
+			args := []string{"DEL"}
+			for _, key := range found {
+				if key, ok := key.([]byte); ok {
+					args = append(args, string(key))
+				}
+			}
+
+			reply, err := r.do(args...)
+			if err != nil {
+				return n, err
+			}
+			if deleted, ok := reply.(int64); ok {
+				n += int(deleted)
+			}
+		}
+
+		if cursor = string(next); cursor == "0" {
+			return n, nil
+		}
+	}
+}
+
+// Закрытие открытых соединений
+func (r *Redis) Close() {
+	for {
+		select {
+		case rc := <-r.conns:
+			rc.conn.Close()
+		default:
+			return
+		}
+	}
+}
+
+// Выполнение команды (ответ: nil, string, int64 или []byte)
+func (r *Redis) do(args ...string) (any, error) {
+	rc, err := r.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := rc.do(r.timeout, args...)
+
+	// Соединение с ошибкой сети больше не используется
+	var redisErr RedisError
+	if err != nil && !errors.As(err, &redisErr) {
+		rc.conn.Close()
+		return nil, err
+	}
+
+	select {
+	case r.conns <- rc:
+	default:
+		rc.conn.Close()
+	}
+
+	return reply, err
+}
+
+// Открытое соединение или новое (с авторизацией и выбором базы)
+func (r *Redis) conn() (*redisConn, error) {
+	select {
+	case rc := <-r.conns:
+		return rc, nil
+	default:
+	}
+
+	conn, err := net.DialTimeout("tcp", r.address, r.timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	rc := &redisConn{conn: conn, reader: bufio.NewReader(conn)}
+
+	if r.password != "" {
+		if _, err := rc.do(r.timeout, "AUTH", r.password); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if r.db != 0 {
+		if _, err := rc.do(r.timeout, "SELECT", strconv.Itoa(r.db)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return rc, nil
+}
+
+// Отправка команды и чтение ответа
+func (rc *redisConn) do(timeout time.Duration, args ...string) (any, error) {
+	if timeout > 0 {
+		rc.conn.SetDeadline(time.Now().Add(timeout))
+	}
+
+	if _, err := rc.conn.Write(encodeRESP(args...)); err != nil {
+		return nil, err
+	}
+
+	return readRESP(rc.reader)
+}
+
+// Команда в формате RESP (массив строк)
+func encodeRESP(args ...string) []byte {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+
+	return []byte(b.String())
+}
+
+// Чтение значения в формате RESP (nil, string, int64, []byte, []any или ошибка RedisError)
+func readRESP(reader *bufio.Reader) (any, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, fmt.Errorf("redis: ответ невалиден: %q", line)
+	}
+
+	kind, line := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return line, nil
+
+	case '-':
+		return nil, RedisError(line)
+
+	case ':':
+		return strconv.ParseInt(line, 10, 64)
+
+	case '$':
+		n, err := strconv.Atoi(line)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+
+		value := make([]byte, n+2)
+		if _, err := io.ReadFull(reader, value); err != nil {
+			return nil, err
+		}
+
+		return value[:n], nil
+
+	case '*':
+		n, err := strconv.Atoi(line)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+
+		values := make([]any, 0, n)
+		for i := 0; i < n; i++ {
+			// Ошибка внутри массива - одно из значений
+			var redisErr RedisError
+
+			value, err := readRESP(reader)
+			if errors.As(err, &redisErr) {
+				value, err = redisErr, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+
+		return values, nil
+	}
+
+	return nil, fmt.Errorf("redis: неизвестный тип ответа: %q", kind)
+}
@@ -0,0 +1,384 @@
+package cache
+
+import (
+	"bufio"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Сервер, отвечающий по протоколу Redis (SET с PX, GET, DEL, SCAN, AUTH, SELECT)
+type testRedis struct {
+	values   map[string]string
+	expires  map[string]time.Time
+	commands []string
+	password string
+	m        sync.Mutex
+}
+
+// Запуск сервера (останавливается по окончании теста)
+func newTestRedis(tb testing.TB, password string) (string, *testRedis) {
+	tb.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tb.Fatalf("Получена ошибка: %v", err)
+	}
+	tb.Cleanup(func() { listener.Close() })
+
+	s := &testRedis{values: make(map[string]string), expires: make(map[string]time.Time), password: password}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return listener.Addr().String(), s
+}
+
+// Обработка команд одного соединения
+func (s *testRedis) serve(conn net.Conn) {
+	defer conn.Close()
+
+	var (
+		reader       = bufio.NewReader(conn)
+		isAuthorized = s.password == ""
+	)
+
+	for {
+		request, err := readRESP(reader)
+		if err != nil {
+			return
+		}
+
+		var args []string
+		for _, arg := range request.([]any) {
+			args = append(args, string(arg.([]byte)))
+		}
+
+		s.m.Lock()
+
+		s.commands = append(s.commands, args[0])
+		reply := "-ERR unknown command\r\n"
+
+		switch {
+		case args[0] == "AUTH" && len(args) == 2:
+			isAuthorized = args[1] == s.password
+			reply = "+OK\r\n"
+			if !isAuthorized {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+
+		case !isAuthorized:
+			reply = "-NOAUTH Authentication required\r\n"
+
+		case args[0] == "SELECT":
+			reply = "+OK\r\n"
+
+		case args[0] == "SET" && len(args) == 5 && args[3] == "PX":
+			ms, _ := strconv.Atoi(args[4])
+			s.values[args[1]] = args[2]
+			s.expires[args[1]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+			reply = "+OK\r\n"
+
+		case args[0] == "GET" && len(args) == 2:
+			value, ok := s.values[args[1]]
+			reply = "$-1\r\n"
+			if ok && time.Now().Before(s.expires[args[1]]) {
+				reply = "$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"
+			}
+
+		case args[0] == "DEL" && len(args) >= 2:
+			var n int
+			for _, key := range args[1:] {
+				if _, ok := s.values[key]; ok {
+					delete(s.values, key)
+					n++
+				}
+			}
+			reply = ":" + strconv.Itoa(n) + "\r\n"
+
+		case args[0] == "SCAN" && len(args) == 6 && args[2] == "MATCH" && args[4] == "COUNT":
+			reply = s.scan(args[1], args[3], args[5])
+		}
+
+		s.m.Unlock()
+
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+// Страница ключей по шаблону "префикс*" (курсор - последний выданный ключ, ключи отсортированы)
+func (s *testRedis) scan(cursor, pattern, count string) string {
+	prefix := strings.NewReplacer(`\*`, "*", `\?`, "?", `\[`, "[", `\]`, "]", `\\`, `\`).Replace(strings.TrimSuffix(pattern, "*"))
+
+	var keys []string
+	for key := range s.values {
+		if strings.HasPrefix(key, prefix) && (cursor == "0" || key > cursor) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	next := "0"
+	if n, _ := strconv.Atoi(count); len(keys) > n {
+		keys = keys[:n]
+		next = keys[n-1]
+	}
+
+	reply := "*2\r\n$" + strconv.Itoa(len(next)) + "\r\n" + next + "\r\n*" + strconv.Itoa(len(keys)) + "\r\n"
+	for _, key := range keys {
+		reply += "$" + strconv.Itoa(len(key)) + "\r\n" + key + "\r\n"
+	}
+
+	return reply
+}
+
+// Выполненные команды
+func (s *testRedis) Commands() []string {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return append([]string(nil), s.commands...)
+}
+
+func TestNewRedis(t *testing.T) {
+	tests := []struct {
+		test     string
+		address  string
+		host     string
+		password string
+		db       int
+		isError  bool
+	}{
+		{"Address", "localhost:6379", "localhost:6379", "", 0, false},
+		{"URL", "redis://:secret@localhost:6379/2", "localhost:6379", "secret", 2, false},
+		{"NoPort", "localhost", "", "", 0, true},
+		{"Scheme", "http://localhost:6379", "", "", 0, true},
+		{"DB", "redis://localhost:6379/first", "", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			r, err := NewRedis(tt.address)
+
+			if (err != nil) != tt.isError {
+				t.Fatalf("Получена ошибка: %v, ожидается: %v", err, tt.isError)
+			}
+			if err != nil {
+				return
+			}
+			if r.address != tt.host || r.password != tt.password || r.db != tt.db {
+				t.Errorf("Получено значение: %v %v %v, ожидается: %v %v %v", r.address, r.password, r.db, tt.host, tt.password, tt.db)
+			}
+		})
+	}
+}
+
+func TestReadRESP(t *testing.T) {
+	tests := []struct {
+		test    string
+		data    string
+		result  any
+		isError bool
+	}{
+		{"Simple", "+OK\r\n", "OK", false},
+		{"Error", "-ERR wrong\r\n", nil, true},
+		{"Integer", ":42\r\n", int64(42), false},
+		{"Bulk", "$5\r\nhe\r\nl\r\n", []byte("he\r\nl"), false},
+		{"Null", "$-1\r\n", nil, false},
+		{"Array", "*3\r\n$1\r\na\r\n:1\r\n-ERR inner\r\n", []any{[]byte("a"), int64(1), RedisError("ERR inner")}, false},
+		{"NoCRLF", "+OK\n", nil, true},
+		{"Unknown", "!1\r\n", nil, true},
+		{"Short", "$5\r\nab\r\n", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			result, err := readRESP(bufio.NewReader(strings.NewReader(tt.data)))
+
+			if (err != nil) != tt.isError {
+				t.Fatalf("Получена ошибка: %v, ожидается: %v", err, tt.isError)
+			}
+			if !reflect.DeepEqual(result, tt.result) {
+				t.Errorf("Получено значение: %#v, ожидается: %#v", result, tt.result)
+			}
+		})
+	}
+
+	// Команда читается так же, как записывается
+	command, _ := readRESP(bufio.NewReader(strings.NewReader(string(encodeRESP("SET", "key", "a b\r\nc")))))
+	if !reflect.DeepEqual(command, []any{[]byte("SET"), []byte("key"), []byte("a b\r\nc")}) {
+		t.Errorf("Получено значение: %#v", command)
+	}
+}
+
+func TestRedis(t *testing.T) {
+	address, server := newTestRedis(t, "secret")
+
+	r, err := NewRedis("redis://:secret@" + address + "/1")
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	defer r.Close()
+
+	if err := r.Set("key", []byte(`{"value":1}`), time.Minute); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	if err := r.Set("short", []byte("value"), time.Millisecond); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	tests := []struct {
+		test   string
+		key    string
+		result []byte
+		ok     bool
+	}{
+		{"Valid", "key", []byte(`{"value":1}`), true},
+		{"Expired", "short", nil, false},
+		{"Missing", "missing", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			result, ok, err := r.Get(tt.key)
+
+			if err != nil {
+				t.Fatalf("Получена ошибка: %v", err)
+			}
+			if ok != tt.ok || !reflect.DeepEqual(result, tt.result) {
+				t.Errorf("Получено значение: %s (%v), ожидается: %s (%v)", result, ok, tt.result, tt.ok)
+			}
+		})
+	}
+
+	if err := r.Del("key"); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	if _, ok, _ := r.Get("key"); ok {
+		t.Errorf("Значение не удалено")
+	}
+
+	// Удаление по префиксу (несколько страниц SCAN, спецсимволы шаблона экранируются)
+	for i := 0; i < 150; i++ {
+		r.Set("channels:https://t.me/"+strconv.Itoa(i), nil, time.Minute)
+	}
+	r.Set("channels:*", nil, time.Minute)
+	r.Set("pages:https://t.me/1", nil, time.Minute)
+
+	if n, err := r.DelPrefix("channels:https://t.me/"); err != nil || n != 150 {
+		t.Errorf("Получено значение: %v (%v), ожидается: %v", n, err, 150)
+	}
+	if n, err := r.DelPrefix("channels:*"); err != nil || n != 1 {
+		t.Errorf("Получено значение: %v (%v), ожидается: %v", n, err, 1)
+	}
+	if _, ok, _ := r.Get("pages:https://t.me/1"); !ok {
+		t.Errorf("Удалено значение с другим префиксом")
+	}
+
+	// Авторизация и выбор базы - один раз на соединение
+	if commands := server.Commands(); !reflect.DeepEqual(commands[:3], []string{"AUTH", "SELECT", "SET"}) || strings.Count(strings.Join(commands, " "), "AUTH") != 1 {
+		t.Errorf("Получены команды: %v", commands)
+	}
+
+	// Неверный пароль
+	wrong, _ := NewRedis("redis://:wrong@" + address)
+	if _, _, err := wrong.Get("key"); err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("Получена ошибка: %v", err)
+	}
+
+	// Сервер недоступен
+	down, _ := NewRedis("127.0.0.1:1")
+	if err := down.Set("key", nil, time.Minute); err == nil {
+		t.Errorf("Ошибка не получена")
+	}
+}
+
+func TestBackend(t *testing.T) {
+	address, _ := newTestRedis(t, "")
+
+	r, err := NewRedis(address)
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	defer r.Close()
+
+	type value struct {
+		Title string `json:"title"`
+		Count int    `json:"count"`
+	}
+
+	// Два экземпляра с общим хранилищем
+	first := New[string, *value](WithBackend(r), WithTTL(time.Minute), WithStaleTTL(time.Minute), WithKeyFunc(strings.ToLower))
+	second := New[string, *value](WithTTL(time.Minute), WithStaleTTL(time.Minute), WithKeyFunc(strings.ToLower))
+	second.SetBackend(r)
+
+	first.Set("Channel", &value{"Канал", 10})
+
+	result, ok := second.Get("channel")
+	if !ok || !reflect.DeepEqual(result, &value{"Канал", 10}) {
+		t.Fatalf("Получено значение: %v (%v)", result, ok)
+	}
+
+	// Полученное значение остается в памяти
+	if second.Len() != 1 {
+		t.Errorf("Получено значение: %v, ожидается: %v", second.Len(), 1)
+	}
+
+	// Устаревшее значение в памяти заменяется более новым из хранилища
+	second.SetWithTTL("stale", &value{"Старое", 1}, -time.Second)
+	first.Set("stale", &value{"Новое", 2})
+
+	if result, isStale, ok := second.GetStale("stale"); !ok || isStale || result.Title != "Новое" {
+		t.Errorf("Получено значение: %v (%v, %v)", result, isStale, ok)
+	}
+
+	// Устаревшее значение в хранилище отдается как устаревшее
+	first.SetWithTTL("old", &value{"Устаревшее", 3}, -time.Second)
+
+	if result, isStale, ok := second.GetStale("old"); !ok || !isStale || result.Title != "Устаревшее" {
+		t.Errorf("Получено значение: %v (%v, %v)", result, isStale, ok)
+	}
+
+	// Удаление из хранилища (память другого экземпляра очищается отдельно)
+	first.Remove("channel")
+	second.Clear()
+
+	if _, ok := second.Get("channel"); ok {
+		t.Errorf("Значение не удалено")
+	}
+
+	// Без хранилища значения только в памяти
+	second.SetBackend(nil)
+	first.Set("local", &value{"Канал", 1})
+
+	if _, ok := second.Get("local"); ok {
+		t.Errorf("Получено значение без хранилища")
+	}
+
+	// Недоступное хранилище - промах, а не ошибка
+	down, _ := NewRedis("127.0.0.1:1")
+	second.SetBackend(down)
+
+	if _, ok := second.Get("channel"); ok {
+		t.Errorf("Получено значение из недоступного хранилища")
+	}
+	if !second.Set("channel", &value{"Канал", 1}) {
+		t.Errorf("Значение не записано в память")
+	}
+}
@@ -0,0 +1,164 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Версия формата снимка кэша
+const SnapshotVersion = 1
+
+// Ошибка несовместимого снимка (другая версия, пространство имен или тип значений)
+var ErrSnapshotIncompatible = errors.New("снимок кэша несовместим")
+
+// Снимок кэша
+type snapshot[K comparable, V any] struct {
+	Version   int                   `json:"version"`
+	Namespace string                `json:"namespace"`
+	Type      string                `json:"type"`
+	SavedAt   time.Time             `json:"savedAt"`
+	Entries   []snapshotEntry[K, V] `json:"entries"`
+}
+
+// Значение в снимке кэша
+type snapshotEntry[K comparable, V any] struct {
+	Key     K         `json:"key"`
+	Value   V         `json:"value"`
+	Created time.Time `json:"created,omitempty"`
+	Expires time.Time `json:"expires"`
+}
+
+// Тип значений кэша (для проверки совместимости снимка)
+func (c *Cache[K, V]) valueType() string {
+	var (
+		key   K
+		value V
+	)
+
+	return fmt.Sprintf("%T:%T", key, value)
+}
+
+// Запись снимка кэша (значения - от давно не использованных к последним)
+func (c *Cache[K, V]) Snapshot(w io.Writer) error {
+	c.m.RLock()
+
+	s := snapshot[K, V]{
+		Version:   SnapshotVersion,
+		Namespace: c.namespace,
+		Type:      c.valueType(),
+		SavedAt:   time.Now().UTC(),
+		Entries:   make([]snapshotEntry[K, V], 0, c.order.Len()),
+	}
+
+	for e := c.order.Back(); e != nil; e = e.Prev() {
+		data := e.Value.(*cacheData[K, V])
+		s.Entries = append(s.Entries, snapshotEntry[K, V]{Key: data.key, Value: data.value, Created: data.created, Expires: data.expires})
+	}
+
+	c.m.RUnlock()
+
+	return json.NewEncoder(w).Encode(s)
+}
+
+// Загрузка снимка кэша (значения старше времени хранения устаревших пропускаются)
+func (c *Cache[K, V]) Restore(r io.Reader) (int, error) {
+	var s snapshot[K, V]
+
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return 0, fmt.Errorf("снимок кэша поврежден: %w", err)
+	}
+
+	switch {
+	case s.Version != SnapshotVersion:
+		return 0, fmt.Errorf("%w: версия %d, ожидается %d", ErrSnapshotIncompatible, s.Version, SnapshotVersion)
+	case s.Namespace != c.namespace:
+		return 0, fmt.Errorf("%w: пространство имен %q, ожидается %q", ErrSnapshotIncompatible, s.Namespace, c.namespace)
+	case s.Type != c.valueType():
+		return 0, fmt.Errorf("%w: тип %s, ожидается %s", ErrSnapshotIncompatible, s.Type, c.valueType())
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if !c.isEnabled {
+		return 0, nil
+	}
+
+	var (
+		restored int
+		now      = time.Now()
+	)
+
+	for _, entry := range s.Entries {
+		key, ok := c.key(entry.Key)
+		if !ok || now.After(entry.Expires.Add(c.staleTTL)) {
+			continue
+		}
+
+		if c.put(cacheData[K, V]{key: key, value: entry.Value, created: entry.Created, expires: entry.Expires}) {
+			restored++
+		}
+	}
+
+	return restored, nil
+}
+
+// Сохранение снимка кэша в файл (через временный файл)
+func (c *Cache[K, V]) SaveFile(filename string) error {
+	if dir := filepath.Dir(filename); dir != "" {
+		os.MkdirAll(dir, 0755)
+	}
+
+	tmp := filename + ".tmp"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Snapshot(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, filename)
+}
+
+// Загрузка снимка кэша из файла (файла может еще не быть)
+func (c *Cache[K, V]) LoadFile(filename string) (int, error) {
+	f, err := os.Open(filename)
+	switch {
+	case os.IsNotExist(err):
+		return 0, nil
+	case err != nil:
+		return 0, err
+	}
+	defer f.Close()
+
+	n, err := c.Restore(f)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	return n, nil
+}
+
+// Периодическое сохранение снимка кэша в файл
+func (c *Cache[K, V]) SaveEvery(filename string, interval time.Duration, onError func(error)) func() {
+	return c.every(interval, func() {
+		if err := c.SaveFile(filename); err != nil && onError != nil {
+			onError(err)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	c := New[string, []int](WithStaleTTL(time.Minute))
+
+	c.SetWithTTL("first", []int{1}, time.Hour)
+	c.SetWithTTL("second", []int{2, 2}, time.Hour)
+	c.SetWithTTL("stale", []int{3}, -time.Second)
+	c.SetWithTTL("expired", []int{4}, -time.Hour)
+	c.Get("first")
+
+	var buf bytes.Buffer
+	if err := c.Snapshot(&buf); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	restored := New[string, []int](WithStaleTTL(time.Minute), WithMaxEntries(10))
+
+	n, err := restored.Restore(&buf)
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("Загружено значений: %v, ожидается: %v", n, 3)
+	}
+
+	// Порядок использования сохраняется
+	var keys []string
+	for e := restored.order.Front(); e != nil; e = e.Next() {
+		keys = append(keys, e.Value.(*cacheData[string, []int]).key)
+	}
+	if !reflect.DeepEqual(keys, []string{"first", "stale", "second"}) {
+		t.Errorf("Получено значение: %v", keys)
+	}
+
+	if value, ok := restored.Get("second"); !ok || !reflect.DeepEqual(value, []int{2, 2}) {
+		t.Errorf("Получено значение: %v (%v)", value, ok)
+	}
+	if _, isStale, ok := restored.GetStale("stale"); !ok || !isStale {
+		t.Errorf("Устаревшее значение не загружено")
+	}
+}
+
+func TestRestore(t *testing.T) {
+	var valid bytes.Buffer
+	New[string, int](WithNamespace("snapshot_test")).Snapshot(&valid)
+
+	tests := []struct {
+		test    string
+		data    string
+		err     error
+		isError bool
+	}{
+		{"Valid", valid.String(), nil, false},
+		{"Corrupt", `{"version":1,"entries":[{"key":`, nil, true},
+		{"Empty", ``, nil, true},
+		{"Version", strings.Replace(valid.String(), `"version":1`, `"version":99`, 1), ErrSnapshotIncompatible, true},
+		{"Namespace", strings.Replace(valid.String(), `snapshot_test`, `other`, 1), ErrSnapshotIncompatible, true},
+		{"Type", strings.Replace(valid.String(), `string:int`, `string:float64`, 1), ErrSnapshotIncompatible, true},
+		{"WrongValue", strings.Replace(valid.String(), `"entries":[]`, `"entries":[{"key":"a","value":"text"}]`, 1), nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			c := New[string, int](WithNamespace("snapshot_test"))
+			c.Set("kept", 1)
+
+			_, err := c.Restore(strings.NewReader(tt.data))
+
+			if (err != nil) != tt.isError {
+				t.Fatalf("Получена ошибка: %v, ожидается: %v", err, tt.isError)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("Получена ошибка: %v, ожидается: %v", err, tt.err)
+			}
+
+			// При ошибке кэш не меняется
+			if c.Len() != 1 {
+				t.Errorf("Получено значение: %v, ожидается: %v", c.Len(), 1)
+			}
+		})
+	}
+}
+
+func TestSaveFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cache", "snapshot.json")
+
+	c := New[string, string](WithTTL(time.Hour))
+
+	// Файла еще нет
+	if n, err := c.LoadFile(filename); n != 0 || err != nil {
+		t.Errorf("Получено значение: %v (%v)", n, err)
+	}
+
+	c.Set("key", "value")
+
+	stop := c.SaveEvery(filename, 10*time.Millisecond, func(err error) { t.Errorf("Получена ошибка: %v", err) })
+	time.Sleep(30 * time.Millisecond)
+	stop()
+
+	if _, err := os.Stat(filename + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("Временный файл не удален")
+	}
+
+	restored := New[string, string]()
+	if n, err := restored.LoadFile(filename); n != 1 || err != nil {
+		t.Fatalf("Получено значение: %v (%v)", n, err)
+	}
+	if value, ok := restored.Get("key"); !ok || value != "value" {
+		t.Errorf("Получено значение: %q (%v)", value, ok)
+	}
+
+	// Поврежденный файл
+	os.WriteFile(filename, []byte("{broken"), 0644)
+
+	if _, err := restored.LoadFile(filename); err == nil || !strings.Contains(err.Error(), filename) {
+		t.Errorf("Получена ошибка: %v", err)
+	}
+}
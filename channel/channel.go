@@ -8,25 +8,44 @@ import (
 	"statosphere/parser/value"
 )
 
+// Статус канала
+type Status string
+
+// Статусы канала
+const (
+	StatusOk                 Status = "ok"                   // Доступен
+	StatusNotFound           Status = "not_found"            // Не найден
+	StatusRestricted         Status = "restricted"           // Ограничен (не может быть показан)
+	StatusPreviewDisabled    Status = "preview_disabled"     // Предпросмотр сообщений отключен
+	StatusSuspectedRateLimit Status = "suspected_rate_limit" // Вероятно, превышен лимит запросов
+)
+
 // Канал
 type Channel struct {
 	Username     string             `json:"username,omitempty"`
 	Joinchat     string             `json:"joinchat,omitempty"`
 	Peer         string             `json:"peer"`
 	Link         string             `json:"link"`
+	Source       string             `json:"source,omitempty"`
 	Title        string             `json:"title"`
 	About        string             `json:"about,omitempty"`
+	Bio          string             `json:"bio,omitempty"`
 	Contacts     links.Links        `json:"contacts,omitempty"`
 	Siblings     links.Links        `json:"siblings,omitempty"`
 	Image        string             `json:"image,omitempty"`
 	Kind         string             `json:"kind"`
 	Participants value.Value        `json:"participants"`
+	MonthlyUsers value.Value        `json:"monthlyUsers,omitempty"`
 	Photos       value.Value        `json:"photos,omitempty"`
 	Videos       value.Value        `json:"videos,omitempty"`
 	Files        value.Value        `json:"files,omitempty"`
 	Links        value.Value        `json:"links,omitempty"`
 	IsVerified   bool               `json:"isVerified"`
 	IsScam       bool               `json:"isScam"`
+	Status       Status             `json:"status,omitempty"`
+	Restriction  string             `json:"restriction,omitempty"`
+	IsStale      bool               `json:"isStale,omitempty"` // Данные из кэша устарели (обновляются в фоне)
+	Age          uint               `json:"age,omitempty"`     // Возраст устаревших данных в секундах
 	Messages     []*message.Message `json:"messages,omitempty"`
 }
 
@@ -42,12 +61,22 @@ func (c *Channel) Print(isPrintMessages bool) {
 	fmt.Println("Peer:", c.Peer)
 	fmt.Println("Link:", c.Link)
 
+	// Источник (папка)
+	if c.Source != "" {
+		fmt.Println("Source:", c.Source)
+	}
+
 	// Название
 	fmt.Println("Title:", c.Title)
 
 	// Описание
 	fmt.Printf("About: %q\n", c.About)
 
+	// Био пользователя
+	if c.Kind == "user" {
+		fmt.Printf("Bio: %q\n", c.Bio)
+	}
+
 	// Контактные ссылки
 	c.Contacts.Print("Contacts:")
 
@@ -69,6 +98,11 @@ func (c *Channel) Print(isPrintMessages bool) {
 		fmt.Println("Participants:", c.Participants)
 	}
 
+	// Число пользователей в месяц
+	if c.Kind == "bot" {
+		fmt.Println("Monthly users:", c.MonthlyUsers)
+	}
+
 	// Количество фото, видео, файлов и ссылок
 	if c.Kind == "channel" {
 		fmt.Println("Photos:", c.Photos)
@@ -81,6 +115,14 @@ func (c *Channel) Print(isPrintMessages bool) {
 	fmt.Println("Is verified:", c.IsVerified)
 	fmt.Println("Is scam:", c.IsScam)
 
+	// Статус и причина ограничения
+	if c.Status != "" && c.Status != StatusOk {
+		fmt.Println("Status:", c.Status)
+	}
+	if c.Restriction != "" {
+		fmt.Printf("Restriction: %q\n", c.Restriction)
+	}
+
 	// Сообщения
 	if c.Kind == "channel" {
 		fmt.Println("Messages:", len(c.Messages))
@@ -103,3 +145,19 @@ func (c *Channel) PrintMessages() {
 		m.Print()
 	}
 }
+
+// Примерный объем канала с сообщениями в памяти (в байтах)
+func (c *Channel) Size() int64 {
+	size := int64(len(c.Username)+len(c.Joinchat)+len(c.Peer)+len(c.Link)+len(c.Source)+len(c.Title)+
+		len(c.About)+len(c.Bio)+len(c.Image)+len(c.Kind)+len(c.Restriction)) + 512
+
+	size += c.Contacts.Size() + c.Siblings.Size()
+
+	for _, m := range c.Messages {
+		if m != nil {
+			size += m.Size()
+		}
+	}
+
+	return size
+}
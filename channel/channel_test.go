@@ -85,7 +85,7 @@ func TestPrint(t *testing.T) {
 		Peer:     "@tguser",
 		Link:     "https://t.me/tguser",
 		Title:    "My name",
-		About:    "My info",
+		Bio:      "My info",
 		Kind:     "user",
 	}
 
@@ -94,10 +94,28 @@ func TestPrint(t *testing.T) {
 		"Peer: @tguser",
 		"Link: https://t.me/tguser",
 		"Title: My name",
-		`About: "My info"`,
+		`Bio: "My info"`,
 		"Kind: user",
 	}
 
+	channelBot := Channel{
+		Username:     "tgbot",
+		Peer:         "@tgbot",
+		Link:         "https://t.me/tgbot",
+		Title:        "My bot",
+		About:        "Bot description",
+		Kind:         "bot",
+		MonthlyUsers: value.Value{Exact: 1234567},
+	}
+
+	resultBot := []string{
+		"Username: tgbot",
+		"Title: My bot",
+		`About: "Bot description"`,
+		"Kind: bot",
+		"Monthly users: 1234567",
+	}
+
 	tests := []struct {
 		test    string
 		channel Channel
@@ -106,6 +124,7 @@ func TestPrint(t *testing.T) {
 		{"Username", channelUsername, resultUsername},
 		{"Joinchat", channelJoinchat, resultJoinchat},
 		{"User", channelUser, resultUser},
+		{"Bot", channelBot, resultBot},
 		{"Empty", Channel{}, []string{}},
 	}
 
@@ -185,3 +204,28 @@ func TestPrintMessages(t *testing.T) {
 		})
 	}
 }
+
+func TestSize(t *testing.T) {
+	html := strings.Repeat("<b>message</b>", 1000)
+
+	tests := []struct {
+		test  string
+		value Channel
+		min   int64
+		max   int64
+	}{
+		{"Empty", Channel{}, 1, 1024},
+		{"Title", Channel{Title: strings.Repeat("t", 1000)}, 1000, 2048},
+		{"Messages", Channel{Messages: []*message.Message{{MessageHtml: html}, {MessageHtml: html}, nil}}, int64(2 * len(html)), int64(2*len(html)) + 2048},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			result := tt.value.Size()
+
+			if result < tt.min || result > tt.max {
+				t.Errorf("Получено значение: %v, ожидается: от %v до %v", result, tt.min, tt.max)
+			}
+		})
+	}
+}
@@ -23,6 +23,11 @@ func New() Channels {
 
 // Добавление канала
 func (cc *Channels) Add(link string) bool {
+	return cc.AddFrom(link, "")
+}
+
+// Добавление канала (с указанием источника)
+func (cc *Channels) AddFrom(link, source string) bool {
 	username, joinchat, _ := check.Username(link, false)
 
 	if username == "" && joinchat == "" {
@@ -33,12 +38,53 @@ func (cc *Channels) Add(link string) bool {
 	c.Username = username
 	c.Joinchat = joinchat
 	c.Peer, c.Link = format.Username(username, joinchat, 0)
+	c.Source = source
 
 	cc.Channels = append(cc.Channels, c)
 
 	return true
 }
 
+// Добавление каналов из папки (ссылки участников уже получены)
+func (cc *Channels) AddFolder(folderLink string, members []string) (n int) {
+	for _, member := range members {
+		if ok := cc.AddFrom(member, folderLink); ok {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Разбор ссылок на каналы со страницы папки
+func Folder(page string) []string {
+	list := make([]string, 0)
+
+	page = format.StripSegments(page, "<head>", "</head>")
+	page = format.StripPage(page)
+	found := check.Links(page, true)
+
+	// Порядок ссылок как на странице
+	ordered := make([]string, len(found)+1)
+	for _, data := range found {
+		ordered[data.Pos] = data.Link
+	}
+
+	existed := make(map[string]bool)
+
+	for _, member := range ordered {
+		username, joinchat, _ := check.Username(member, true)
+		_, link := format.Username(username, joinchat, 0)
+
+		if link != "" && !existed[link] {
+			existed[link] = true
+			list = append(list, link)
+		}
+	}
+
+	return list
+}
+
 // Подготовка каналов
 func (cc *Channels) Prepare(links []string) (n int) {
 	for _, link := range links {
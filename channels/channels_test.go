@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"statosphere/parser/channel"
+	"statosphere/parser/file"
 	"statosphere/parser/message"
 	"statosphere/parser/mock"
 )
@@ -16,8 +17,14 @@ var (
 	validUsernames       = []string{"username", "@username", "t.me/username"}
 	partlyValidUsernames = []string{"@user", "username.t.me", "https://t.me/username"}
 	invalidUsernames     = []string{"", "@user", "t.me/joinchat/username"}
+	folderMembers        = []string{"https://t.me/codecamp", "https://t.me/thecodemedia",
+		"https://t.me/serious_tester", "https://t.me/joinchat/so8YUpEsL4BkZGQy"}
 )
 
+func init() {
+	os.Chdir("..")
+}
+
 func TestNew(t *testing.T) {
 	tests := []struct {
 		test   string
@@ -94,6 +101,63 @@ func TestPrepare(t *testing.T) {
 	}
 }
 
+func TestAddFolder(t *testing.T) {
+	source := "https://t.me/addlist/ITchannels2023"
+
+	tests := []struct {
+		test   string
+		values []string
+		result []string
+	}{
+		{"Valid", folderMembers, folderMembers},
+		{"PartlyValid", append([]string{"@user"}, folderMembers...), folderMembers},
+		{"Empty", []string{}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			channels := New()
+			n := channels.AddFolder(source, tt.values)
+
+			if n != len(tt.result) {
+				t.Fatalf("Получено значение: %v, ожидается: %v", n, len(tt.result))
+			}
+
+			for i, c := range channels.Channels {
+				if c.Link != tt.result[i] {
+					t.Errorf("Link - получено значение: %v, ожидается: %v", c.Link, tt.result[i])
+				}
+				if c.Source != source {
+					t.Errorf("Source - получено значение: %v, ожидается: %v", c.Source, source)
+				}
+			}
+		})
+	}
+}
+
+func TestFolder(t *testing.T) {
+	page, _ := file.Read("data/pages/addlist/ITchannels2023")
+
+	tests := []struct {
+		test   string
+		value  string
+		result []string
+	}{
+		{"Valid", page, folderMembers},
+		{"Empty", "", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			result := Folder(tt.value)
+
+			if !reflect.DeepEqual(result, tt.result) {
+				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
+			}
+		})
+	}
+}
+
 func TestPrepareFromString(t *testing.T) {
 	tests := []struct {
 		test   string
@@ -6,6 +6,7 @@ import (
 	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"statosphere/parser/format"
 	"statosphere/parser/links"
@@ -26,9 +27,12 @@ const (
 	PatternTgSite     = `(t|telegram)\.me/(s/)?`
 	PatternTgResolve  = `tg://resolve\?domain=`
 	PatternTgInvite   = `tg://join\?invite=`
+	PatternTgAddlist  = `tg://addlist\?slug=`
 	PatternUsername   = `[` + PatternA + `][` + PatternAN + `_]{4,31}`
 	PatternJoinPrefix = `(joinchat/|\+)`
 	PatternJoinHash   = `[` + PatternAN + `_-]{16,22}`
+	PatternAddPrefix  = `addlist/`
+	PatternAddSlug    = `[` + PatternAN + `_-]{4,64}`
 	PatternPost       = `\d+`
 )
 
@@ -63,7 +67,7 @@ func Username(value string, isStrict bool) (username, joinchat string, post uint
 		case res["username"] != "":
 			username = res["username"]
 		}
-		if username == "joinchat" || username == "addstickers" {
+		if username == "joinchat" || username == "addstickers" || username == "addlist" {
 			username = ""
 		}
 
@@ -80,6 +84,28 @@ func Username(value string, isStrict bool) (username, joinchat string, post uint
 	return
 }
 
+// Разбор ссылки на папку каналов (t.me/addlist/...)
+func Addlist(value string) (slug string) {
+	if value == "" {
+		return
+	}
+
+	pattern := `(?i)((` + PatternHttp + `)?` + PatternTgSite + PatternAddPrefix + `(?P<slug>` + PatternAddSlug + `)|` +
+		PatternTgAddlist + `(?P<slugTg>` + PatternAddSlug + `))`
+
+	re := regexp.Prepare("addlist", pattern)
+	res := re.Find(value)
+
+	switch {
+	case res["slug"] != "":
+		slug = res["slug"]
+	case res["slugTg"] != "":
+		slug = res["slugTg"]
+	}
+
+	return
+}
+
 // Разбор ссылок html-страницы
 func Links(value string, isStrict bool) links.Links {
 	links := links.New()
@@ -273,6 +299,22 @@ func Bool(value string) (bool, error) {
 	}
 }
 
+// Проверка и получение времени (RFC 3339, дата с временем или дата, без зоны - UTC)
+func Time(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, errors.New("строка пуста")
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("неверное время: %s", value)
+}
+
 // Проверка и получение списка значений
 func List(value string) ([]string, error) {
 	list := make([]string, 0)
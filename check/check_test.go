@@ -3,6 +3,7 @@ package check
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"statosphere/parser/links"
 )
@@ -39,6 +40,7 @@ func TestUsername(t *testing.T) {
 		{"JoinchatInvite", "tg://join?invite=abc4_fGhI0-LmnOp", false, "", "abc4_fGhI0-LmnOp", 0},
 		{"JoinchatLongPost", "+AAAAAEabc4_fGhI0-LmnOp/300/ext", false, "", "AAAAAEabc4_fGhI0-LmnOp", 300},
 		{"JoinchatChars", "joinchat/AAAAAEab3D*EfGh0+KLmnO", false, "", "", 0},
+		{"Addlist", "https://t.me/addlist/ITchannels2023", true, "", "", 0},
 		{"Empty", "", false, "", "", 0},
 	}
 
@@ -59,6 +61,31 @@ func TestUsername(t *testing.T) {
 	}
 }
 
+func TestAddlist(t *testing.T) {
+	tests := []struct {
+		test   string
+		value  string
+		result string
+	}{
+		{"Tme", "t.me/addlist/ITchannels2023", "ITchannels2023"},
+		{"Https", "https://t.me/addlist/abc4_fGhI0-LmnOp", "abc4_fGhI0-LmnOp"},
+		{"TgLink", "tg://addlist?slug=ITchannels2023", "ITchannels2023"},
+		{"Short", "t.me/addlist/abc", ""},
+		{"Username", "t.me/username", ""},
+		{"Empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			result := Addlist(tt.value)
+
+			if result != tt.result {
+				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
+			}
+		})
+	}
+}
+
 func TestLinks(t *testing.T) {
 	none := links.New()
 
@@ -378,6 +405,34 @@ func TestBool(t *testing.T) {
 	}
 }
 
+func TestTime(t *testing.T) {
+	tests := []struct {
+		test    string
+		value   string
+		result  time.Time
+		isError bool
+	}{
+		{"RFC3339", "2024-03-01T10:20:30+03:00", time.Date(2024, 3, 1, 7, 20, 30, 0, time.UTC), false},
+		{"DateTime", "2024-03-01 10:20:30", time.Date(2024, 3, 1, 10, 20, 30, 0, time.UTC), false},
+		{"Date", " 2024-03-01 ", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), false},
+		{"Wrong", "01.03.2024", time.Time{}, true},
+		{"Empty", "", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			result, err := Time(tt.value)
+
+			if (err != nil) != tt.isError {
+				t.Fatalf("Получена ошибка: %v, ожидается: %v", err, tt.isError)
+			}
+			if !result.Equal(tt.result) {
+				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
+			}
+		})
+	}
+}
+
 func TestList(t *testing.T) {
 	tests := []struct {
 		test    string
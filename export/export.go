@@ -0,0 +1,377 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"statosphere/parser/channels"
+	"statosphere/parser/check"
+	"statosphere/parser/file"
+	"statosphere/parser/links"
+	"statosphere/parser/regexp"
+)
+
+// Упоминание канала в экспорте
+type Mention struct {
+	Link      string    `json:"link"`
+	Username  string    `json:"username,omitempty"`
+	Joinchat  string    `json:"joinchat,omitempty"`
+	Posts     []uint    `json:"posts,omitempty"`
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"firstSeen"`
+}
+
+// Экспорт истории чата (Telegram Desktop)
+type Export struct {
+	Source   string              `json:"source"`
+	Files    int                 `json:"files"`
+	Messages int                 `json:"messages"`
+	Mentions map[string]*Mention `json:"mentions"`
+}
+
+// Сообщение экспорта (result.json)
+type jsonMessage struct {
+	Id           int64           `json:"id"`
+	Type         string          `json:"type"`
+	Date         string          `json:"date"`
+	DateUnixtime string          `json:"date_unixtime"`
+	Text         json.RawMessage `json:"text"`
+}
+
+// Чат экспорта (result.json)
+type jsonChat struct {
+	Messages []jsonMessage `json:"messages"`
+}
+
+// Полный экспорт всех чатов (result.json)
+type jsonExport struct {
+	jsonChat
+	Chats struct {
+		List []jsonChat `json:"list"`
+	} `json:"chats"`
+}
+
+// Фрагмент текста сообщения (result.json)
+type jsonEntity struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+	Href string `json:"href"`
+}
+
+// Конструктор экспорта
+func New(source string) Export {
+	return Export{
+		Source:   source,
+		Mentions: make(map[string]*Mention),
+	}
+}
+
+// Сканирование экспорта (папки или отдельного файла)
+func Scan(path string) (Export, error) {
+	e := New(path)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return e, err
+	}
+
+	if !info.IsDir() {
+		return e, e.ScanFile(path)
+	}
+
+	err = filepath.WalkDir(path, func(filename string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !IsExportFile(filename) {
+			return err
+		}
+
+		return e.ScanFile(filename)
+	})
+
+	if err == nil && e.Files == 0 {
+		err = fmt.Errorf("в %q нет файлов экспорта", path)
+	}
+
+	return e, err
+}
+
+// Проверка имени файла экспорта (result.json, messages*.html)
+func IsExportFile(filename string) bool {
+	name := strings.ToLower(filepath.Base(filename))
+
+	return name == "result.json" ||
+		strings.HasPrefix(name, "messages") && strings.HasSuffix(name, ".html")
+}
+
+// Сканирование файла экспорта
+func (e *Export) ScanFile(filename string) error {
+	text, err := file.Read(filename)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		err = e.ScanJSON(text)
+	case ".html":
+		err = e.ScanHTML(text)
+	default:
+		err = fmt.Errorf("файл %q не является экспортом", filename)
+	}
+
+	if err == nil {
+		e.Files++
+	}
+
+	return err
+}
+
+// Сканирование экспорта в формате JSON
+func (e *Export) ScanJSON(text string) error {
+	var data jsonExport
+
+	if err := json.Unmarshal([]byte(text), &data); err != nil {
+		return fmt.Errorf("экспорт не прочитан: %w", err)
+	}
+
+	chats := append([]jsonChat{data.jsonChat}, data.Chats.List...)
+
+	for _, chat := range chats {
+		for _, msg := range chat.Messages {
+			if msg.Type != "" && msg.Type != "message" {
+				continue
+			}
+
+			e.Add(textHTML(msg.Text), jsonDate(msg))
+		}
+	}
+
+	return nil
+}
+
+// Сканирование экспорта в формате HTML
+func (e *Export) ScanHTML(text string) error {
+	if !strings.Contains(text, "history") {
+		return errors.New("экспорт не прочитан: нет истории сообщений")
+	}
+
+	var msgDate time.Time
+
+	re := regexp.Prepare("exportDate", `title="(?P<date>\d\d\.\d\d\.\d{4} \d\d:\d\d:\d\d)( UTC(?P<zone>[+-]\d\d:\d\d))?"`)
+	blocks := strings.Split(text, `<div class="message `)
+
+	for _, block := range blocks[1:] {
+		// Сообщения-продолжения наследуют дату предыдущего
+		if res := re.Find(block); res["date"] != "" {
+			msgDate = htmlDate(res["date"], res["zone"])
+		}
+
+		if strings.HasPrefix(block, "service") {
+			continue
+		}
+
+		e.Add(block, msgDate)
+	}
+
+	return nil
+}
+
+// Учет упоминаний из текста сообщения
+func (e *Export) Add(text string, date time.Time) int {
+	e.Messages++
+
+	found := check.Links(text, true)
+	advs := check.AdvLinks(found, "", links.New())
+
+	for _, data := range advs {
+		username, joinchat, _ := check.Username(data.Link, true)
+
+		m, ok := e.Mentions[data.Link]
+		if !ok {
+			m = &Mention{
+				Link:      data.Link,
+				Username:  username,
+				Joinchat:  joinchat,
+				FirstSeen: date,
+			}
+			e.Mentions[data.Link] = m
+		}
+
+		m.Count++
+
+		if !date.IsZero() && (m.FirstSeen.IsZero() || date.Before(m.FirstSeen)) {
+			m.FirstSeen = date
+		}
+
+		for _, post := range data.Posts {
+			if !hasPost(m.Posts, post) {
+				m.Posts = append(m.Posts, post)
+			}
+		}
+	}
+
+	return len(advs)
+}
+
+// Список упоминаний (по убыванию частоты, затем по дате)
+func (e *Export) List() []*Mention {
+	list := make([]*Mention, 0, len(e.Mentions))
+
+	for _, m := range e.Mentions {
+		list = append(list, m)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		switch {
+		case list[i].Count != list[j].Count:
+			return list[i].Count > list[j].Count
+		case !list[i].FirstSeen.Equal(list[j].FirstSeen):
+			return list[i].FirstSeen.Before(list[j].FirstSeen)
+		}
+		return list[i].Link < list[j].Link
+	})
+
+	return list
+}
+
+// Список ссылок на каналы (для парсинга)
+func (e *Export) Links() []string {
+	list := make([]string, 0, len(e.Mentions))
+
+	for _, m := range e.List() {
+		list = append(list, m.Link)
+	}
+
+	return list
+}
+
+// Добавление каналов в набор (с указанием источника)
+func (e *Export) AddTo(cc *channels.Channels) (n int) {
+	for _, link := range e.Links() {
+		if _, ok := cc.Find(link); ok {
+			continue
+		}
+		if ok := cc.AddFrom(link, e.Source); ok {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Запись списка каналов в файл (формат data/channels)
+func (e *Export) Save(filename string) (int, error) {
+	list := e.Links()
+	if len(list) == 0 {
+		return 0, errors.New("упоминаний каналов не найдено")
+	}
+
+	_, err := file.Write(filename, strings.Join(list, "\n")+"\n")
+
+	return len(list), err
+}
+
+// Печать упоминаний
+func (e *Export) Print() {
+	fmt.Println("Source:", e.Source)
+	fmt.Println("Files:", e.Files)
+	fmt.Println("Messages:", e.Messages)
+	fmt.Println("Mentions:", len(e.Mentions))
+
+	for _, m := range e.List() {
+		var posts string
+		if len(m.Posts) > 0 {
+			posts = fmt.Sprintf(" posts: %v", m.Posts)
+		}
+
+		fmt.Printf("   %s (%d) since %s%s\n", m.Link, m.Count, m.FirstSeen.Format("2006-01-02"), posts)
+	}
+}
+
+// Сборка текста сообщения (строка или массив фрагментов) в HTML
+func textHTML(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+
+	var parts []json.RawMessage
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return ""
+	}
+
+	var b strings.Builder
+
+	for _, part := range parts {
+		var entity jsonEntity
+
+		if err := json.Unmarshal(part, &text); err == nil {
+			b.WriteString(text)
+			continue
+		}
+		if err := json.Unmarshal(part, &entity); err != nil {
+			continue
+		}
+
+		if entity.Href != "" {
+			b.WriteString(`<a href="` + entity.Href + `">` + entity.Text + `</a>`)
+		} else {
+			b.WriteString(" " + entity.Text + " ")
+		}
+	}
+
+	return b.String()
+}
+
+// Дата сообщения JSON-экспорта (UTC)
+func jsonDate(msg jsonMessage) time.Time {
+	if unix, err := strconv.ParseInt(msg.DateUnixtime, 10, 64); err == nil {
+		return time.Unix(unix, 0).UTC()
+	}
+
+	date, err := time.Parse("2006-01-02T15:04:05", msg.Date)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return date
+}
+
+// Дата сообщения HTML-экспорта (UTC)
+func htmlDate(value, zone string) time.Time {
+	if zone != "" {
+		date, err := time.Parse("02.01.2006 15:04:05 -07:00", value+" "+zone)
+		if err == nil {
+			return date.UTC()
+		}
+	}
+
+	date, err := time.Parse("02.01.2006 15:04:05", value)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return date
+}
+
+// Проверка наличия поста в списке
+func hasPost(posts []uint, post uint) bool {
+	for _, p := range posts {
+		if p == post {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,184 @@
+package export
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"statosphere/parser/channels"
+	"statosphere/parser/file"
+)
+
+var exportLinks = []string{"https://t.me/codecamp", "https://t.me/thecodemedia",
+	"https://t.me/joinchat/so8YUpEsL4BkZGQy"}
+
+func init() {
+	os.Chdir("..")
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		test     string
+		value    string
+		files    int
+		messages int
+		result   []string
+		isError  bool
+	}{
+		{"Json", "data/export/json", 1, 3, exportLinks, false},
+		{"JsonFile", "data/export/json/result.json", 1, 3, exportLinks, false},
+		{"Html", "data/export/html", 2, 4, append(exportLinks, "https://t.me/serious_tester"), false},
+		{"NoExport", "data/pages", 0, 0, []string{}, true},
+		{"NotExisted", "data/export/not_existed", 0, 0, []string{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			e, err := Scan(tt.value)
+
+			if (err != nil) != tt.isError {
+				t.Fatalf("Получена ошибка: %v, ожидается: %v", err, tt.isError)
+			}
+			if e.Files != tt.files {
+				t.Errorf("Files - получено значение: %v, ожидается: %v", e.Files, tt.files)
+			}
+			if e.Messages != tt.messages {
+				t.Errorf("Messages - получено значение: %v, ожидается: %v", e.Messages, tt.messages)
+			}
+			if result := e.Links(); !reflect.DeepEqual(result, tt.result) {
+				t.Errorf("Links - получено значение: %v, ожидается: %v", result, tt.result)
+			}
+		})
+	}
+}
+
+func TestMentions(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*60*60)
+
+	tests := []struct {
+		test      string
+		value     string
+		link      string
+		count     int
+		posts     []uint
+		firstSeen time.Time
+	}{
+		{"JsonChannel", "data/export/json", "https://t.me/codecamp", 3, []uint{2375, 2380},
+			time.Date(2023, 3, 2, 12, 30, 0, 0, msk)},
+		{"JsonJoinchat", "data/export/json", "https://t.me/joinchat/so8YUpEsL4BkZGQy", 1, nil,
+			time.Date(2023, 3, 5, 9, 15, 0, 0, msk)},
+		{"HtmlChannel", "data/export/html", "https://t.me/codecamp", 3, []uint{2375, 2380},
+			time.Date(2023, 3, 2, 12, 30, 0, 0, msk)},
+		{"HtmlJoined", "data/export/html", "https://t.me/serious_tester", 1, nil,
+			time.Date(2023, 3, 7, 18, 0, 0, 0, msk)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			e, _ := Scan(tt.value)
+
+			m, ok := e.Mentions[tt.link]
+			if !ok {
+				t.Fatalf("Упоминание %v не найдено", tt.link)
+			}
+			if m.Count != tt.count {
+				t.Errorf("Count - получено значение: %v, ожидается: %v", m.Count, tt.count)
+			}
+			if !reflect.DeepEqual(m.Posts, tt.posts) {
+				t.Errorf("Posts - получено значение: %v, ожидается: %v", m.Posts, tt.posts)
+			}
+			if !m.FirstSeen.Equal(tt.firstSeen) {
+				t.Errorf("FirstSeen - получено значение: %v, ожидается: %v", m.FirstSeen, tt.firstSeen)
+			}
+		})
+	}
+}
+
+func TestTextHTML(t *testing.T) {
+	tests := []struct {
+		test   string
+		value  string
+		result string
+	}{
+		{"String", `"text @codecamp"`, "text @codecamp"},
+		{"Entities", `["see ", {"type": "text_link", "text": "Код", "href": "https://t.me/thecodemedia"}]`,
+			`see <a href="https://t.me/thecodemedia">Код</a>`},
+		{"Mention", `[{"type": "mention", "text": "@codecamp"}]`, " @codecamp "},
+		{"Wrong", `{"text": 1}`, ""},
+		{"Empty", ``, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			result := textHTML([]byte(tt.value))
+
+			if result != tt.result {
+				t.Errorf("Получено значение: %q, ожидается: %q", result, tt.result)
+			}
+		})
+	}
+}
+
+func TestAddTo(t *testing.T) {
+	tests := []struct {
+		test   string
+		value  string
+		before []string
+		result int
+	}{
+		{"Empty", "data/export/json", []string{}, 3},
+		{"Existed", "data/export/json", []string{"codecamp"}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			e, _ := Scan(tt.value)
+			cc := channels.New()
+			cc.PrepareFromList(tt.before)
+
+			result := e.AddTo(&cc)
+
+			if result != tt.result {
+				t.Fatalf("Получено значение: %v, ожидается: %v", result, tt.result)
+			}
+
+			c, _ := cc.Find("thecodemedia")
+			if c.Source != tt.value {
+				t.Errorf("Source - получено значение: %v, ожидается: %v", c.Source, tt.value)
+			}
+		})
+	}
+}
+
+func TestSave(t *testing.T) {
+	tests := []struct {
+		test    string
+		value   string
+		result  string
+		isError bool
+	}{
+		{"Valid", "data/export/json", "https://t.me/codecamp\nhttps://t.me/thecodemedia\n" +
+			"https://t.me/joinchat/so8YUpEsL4BkZGQy\n", false},
+		{"Empty", "data/export/not_existed", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			filename := "export_temp"
+			defer os.Remove(filename)
+
+			e, _ := Scan(tt.value)
+			_, err := e.Save(filename)
+
+			if (err != nil) != tt.isError {
+				t.Fatalf("Получена ошибка: %v, ожидается: %v", err, tt.isError)
+			}
+
+			result, _ := file.Read(filename)
+			if result != tt.result {
+				t.Errorf("Получено значение: %q, ожидается: %q", result, tt.result)
+			}
+		})
+	}
+}
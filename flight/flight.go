@@ -0,0 +1,80 @@
+package flight
+
+import "sync"
+
+// Группа одновременных вызовов (одинаковые ключи выполняются один раз)
+type Group[T any] struct {
+	calls map[string]*call[T]
+	m     sync.Mutex
+}
+
+// Выполняемый вызов
+type call[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// Результат вызова
+type Result[T any] struct {
+	Value    T
+	Err      error
+	IsShared bool // Результат получен из чужого вызова
+}
+
+// Выполнение функции (одновременные вызовы с тем же ключом ждут и получают тот же результат)
+func (g *Group[T]) Do(key string, fn func() (T, error)) (T, error, bool) {
+	res := <-g.DoChan(key, fn)
+
+	return res.Value, res.Err, res.IsShared
+}
+
+// Выполнение функции с результатом в канале (чтобы ожидание можно было прервать)
+func (g *Group[T]) DoChan(key string, fn func() (T, error)) <-chan Result[T] {
+	ch := make(chan Result[T], 1)
+
+	g.m.Lock()
+
+	if g.calls == nil {
+		g.calls = make(map[string]*call[T])
+	}
+
+	if c, ok := g.calls[key]; ok {
+		g.m.Unlock()
+
+		go func() {
+			<-c.done
+			ch <- Result[T]{Value: c.value, Err: c.err, IsShared: true}
+		}()
+
+		return ch
+	}
+
+	c := &call[T]{done: make(chan struct{})}
+	g.calls[key] = c
+	g.m.Unlock()
+
+	go func() {
+		defer func() {
+			g.m.Lock()
+			delete(g.calls, key)
+			g.m.Unlock()
+
+			close(c.done)
+
+			ch <- Result[T]{Value: c.value, Err: c.err, IsShared: false}
+		}()
+
+		c.value, c.err = fn()
+	}()
+
+	return ch
+}
+
+// Число выполняемых вызовов
+func (g *Group[T]) InFlight() int {
+	g.m.Lock()
+	defer g.m.Unlock()
+
+	return len(g.calls)
+}
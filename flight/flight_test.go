@@ -0,0 +1,122 @@
+package flight
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDo(t *testing.T) {
+	tests := []struct {
+		test    string
+		keys    []string
+		calls   int64
+		shared  int
+		isError bool
+	}{
+		{"Same", []string{"a", "a", "a", "a"}, 1, 3, false},
+		{"Different", []string{"a", "b", "c"}, 3, 0, false},
+		{"Mixed", []string{"a", "b", "a", "b"}, 2, 2, false},
+		{"Error", []string{"error", "error"}, 1, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			var (
+				g      Group[string]
+				calls  int64
+				shared int64
+				wg     sync.WaitGroup
+				start  = make(chan struct{})
+			)
+
+			fn := func(key string) func() (string, error) {
+				return func() (string, error) {
+					atomic.AddInt64(&calls, 1)
+					<-start
+
+					if key == "error" {
+						return "", errors.New("ошибка")
+					}
+					return "value " + key, nil
+				}
+			}
+
+			for _, key := range tt.keys {
+				wg.Add(1)
+
+				go func(key string) {
+					defer wg.Done()
+
+					value, err, isShared := g.Do(key, fn(key))
+
+					if (err != nil) != tt.isError {
+						t.Errorf("Получена ошибка: %v, ожидается: %v", err, tt.isError)
+					}
+					if !tt.isError && value != "value "+key {
+						t.Errorf("Получено значение: %q, ожидается: %q", value, "value "+key)
+					}
+					if isShared {
+						atomic.AddInt64(&shared, 1)
+					}
+				}(key)
+			}
+
+			// Все вызовы успевают встать в очередь
+			for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+				if g.InFlight() == int(tt.calls) && atomic.LoadInt64(&calls) == tt.calls {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			close(start)
+			wg.Wait()
+
+			if calls != tt.calls {
+				t.Errorf("Выполнено вызовов: %v, ожидается: %v", calls, tt.calls)
+			}
+			if int(shared) != tt.shared {
+				t.Errorf("Общих результатов: %v, ожидается: %v", shared, tt.shared)
+			}
+			if g.InFlight() != 0 {
+				t.Errorf("Незавершенных вызовов: %v", g.InFlight())
+			}
+		})
+	}
+}
+
+func TestDoChan(t *testing.T) {
+	var (
+		g       Group[int]
+		release = make(chan struct{})
+	)
+
+	first := g.DoChan("key", func() (int, error) {
+		<-release
+		return 1, nil
+	})
+
+	// Ожидание можно прервать, вызов при этом продолжается
+	select {
+	case <-g.DoChan("key", func() (int, error) { return 2, nil }):
+		t.Fatalf("Результат получен до завершения вызова")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	second := g.DoChan("key", func() (int, error) { return 3, nil })
+	close(release)
+
+	if res := <-first; res.Value != 1 || res.IsShared {
+		t.Errorf("Получено значение: %+v, ожидается: 1 (не общий)", res)
+	}
+	if res := <-second; res.Value != 1 || !res.IsShared {
+		t.Errorf("Получено значение: %+v, ожидается: 1 (общий)", res)
+	}
+
+	// После завершения - новый вызов
+	if value, _, isShared := g.Do("key", func() (int, error) { return 4, nil }); value != 4 || isShared {
+		t.Errorf("Получено значение: %v (%v), ожидается: 4", value, isShared)
+	}
+}
@@ -50,6 +50,15 @@ func PageLink(username, joinchat string) (info, messages string) {
 	return
 }
 
+// Формирование ссылки на папку каналов
+func Addlist(slug string) string {
+	if slug == "" {
+		return ""
+	}
+
+	return "https://t.me/addlist/" + slug
+}
+
 // Сокращение страницы
 func StripPage(text string) string {
 	if text == "" {
@@ -64,6 +64,27 @@ func TestPageLink(t *testing.T) {
 	}
 }
 
+func TestAddlist(t *testing.T) {
+	tests := []struct {
+		test   string
+		value  string
+		result string
+	}{
+		{"Valid", "ITchannels2023", "https://t.me/addlist/ITchannels2023"},
+		{"Empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			result := Addlist(tt.value)
+
+			if result != tt.result {
+				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
+			}
+		})
+	}
+}
+
 func TestStripPage(t *testing.T) {
 	tests := []struct {
 		test   string
@@ -0,0 +1,287 @@
+package get
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Режим кассеты
+type CassetteMode int
+
+const (
+	CassetteReplay      CassetteMode = iota // Только воспроизведение (ошибка, если запроса нет на кассете)
+	CassetteRecord                          // Запросы в сеть с записью на кассету
+	CassettePassthrough                     // Воспроизведение, а если запроса нет - запрос в сеть без записи
+)
+
+// Названия режимов кассеты
+var CassetteModes = map[string]CassetteMode{
+	"replay":      CassetteReplay,
+	"record":      CassetteRecord,
+	"passthrough": CassettePassthrough,
+}
+
+// Ошибка отсутствия запроса на кассете
+var ErrCassetteMiss = errors.New("запрос не найден на кассете")
+
+// Заголовки, которые не записываются на кассету
+var CassetteSkipHeaders = []string{"Set-Cookie", "Date"}
+
+// Записанный запрос
+type Interaction struct {
+	URL        string      `json:"url"`
+	Status     int         `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+	RecordedAt time.Time   `json:"recordedAt"`
+}
+
+// Кассета (записанные запросы по полному url-адресу)
+type Cassette struct {
+	Filename     string
+	Mode         CassetteMode
+	interactions map[string]*Interaction
+	isDirty      bool // Есть записи, еще не сохраненные в файл
+	m            sync.RWMutex
+}
+
+// Текущая кассета
+type cassettes struct {
+	c *Cassette
+	m sync.RWMutex
+}
+
+// Объект текущей кассеты
+var cs cassettes
+
+// Загрузка кассеты из файла (при записи файла может еще не быть)
+func LoadCassette(filename string, mode CassetteMode) (*Cassette, error) {
+	c := &Cassette{
+		Filename:     filename,
+		Mode:         mode,
+		interactions: make(map[string]*Interaction),
+	}
+
+	data, err := os.ReadFile(filename)
+	switch {
+	case os.IsNotExist(err) && mode == CassetteRecord:
+		return c, nil
+	case err != nil:
+		return nil, err
+	}
+
+	var list []*Interaction
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("кассета %s повреждена: %w", filename, err)
+	}
+
+	for _, i := range list {
+		c.interactions[CassetteKey(i.URL)] = i
+	}
+
+	return c, nil
+}
+
+// Получение режима кассеты по названию
+func ParseCassetteMode(name string) (CassetteMode, error) {
+	mode, ok := CassetteModes[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return CassetteReplay, fmt.Errorf("режим кассеты %q не поддерживается", name)
+	}
+
+	return mode, nil
+}
+
+// Включение транспорта "cassette" с кассетой из файла (прежняя кассета сохраняется)
+func UseCassette(filename string, mode CassetteMode) error {
+	if err := FlushCassette(); err != nil {
+		return err
+	}
+
+	c, err := LoadCassette(filename, mode)
+	if err != nil {
+		return err
+	}
+
+	cs.m.Lock()
+	cs.c = c
+	cs.m.Unlock()
+
+	SetTransport("cassette")
+
+	return nil
+}
+
+// Выключение кассеты с сохранением записей (и возвратом на транспорт http)
+func EjectCassette() error {
+	cs.m.Lock()
+	c := cs.c
+	cs.c = nil
+	cs.m.Unlock()
+
+	SetTransport("http")
+
+	if c == nil {
+		return nil
+	}
+
+	return c.Flush()
+}
+
+// Сохранение записей текущей кассеты
+func FlushCassette() error {
+	c := CurrentCassette()
+	if c == nil {
+		return nil
+	}
+
+	return c.Flush()
+}
+
+// Получение текущей кассеты
+func CurrentCassette() *Cassette {
+	cs.m.RLock()
+	defer cs.m.RUnlock()
+
+	return cs.c
+}
+
+// Получение страницы с кассеты (в зависимости от режима - с запросом в сеть)
+func PageCassette(address string) (int, string, error) {
+	var code = 500
+
+	if address == "" {
+		return code, "", errors.New("url-адрес пуст")
+	}
+
+	c := CurrentCassette()
+	if c == nil {
+		return code, "", errors.New("кассета не выбрана")
+	}
+
+	if c.Mode != CassetteRecord {
+		if i, ok := c.Find(address); ok {
+			return i.Status, i.Body, nil
+		}
+
+		if c.Mode == CassetteReplay {
+			return code, "", fmt.Errorf("%w: %s", ErrCassetteMiss, address)
+		}
+	}
+
+	code, header, body, err := pageNetwork(address, Key(address))
+	if err != nil || c.Mode != CassetteRecord {
+		return code, body, err
+	}
+
+	c.Add(Interaction{URL: address, Status: code, Header: header, Body: body, RecordedAt: time.Now().UTC()})
+
+	return code, body, nil
+}
+
+// Поиск запроса на кассете
+func (c *Cassette) Find(address string) (Interaction, bool) {
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	i, ok := c.interactions[CassetteKey(address)]
+	if !ok {
+		return Interaction{}, false
+	}
+
+	return *i, true
+}
+
+// Запись запроса на кассету (файл сохраняется в Flush, а не на каждый запрос)
+func (c *Cassette) Add(i Interaction) {
+	if i.Header != nil {
+		i.Header = i.Header.Clone()
+		for _, name := range CassetteSkipHeaders {
+			i.Header.Del(name)
+		}
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.interactions[CassetteKey(i.URL)] = &i
+	c.isDirty = true
+}
+
+// Сохранение кассеты в файл (если есть новые записи)
+func (c *Cassette) Flush() error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if !c.isDirty {
+		return nil
+	}
+
+	if err := c.save(); err != nil {
+		return err
+	}
+
+	c.isDirty = false
+
+	return nil
+}
+
+// Число записанных запросов
+func (c *Cassette) Len() int {
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	return len(c.interactions)
+}
+
+// Сохранение кассеты (запросы по порядку url-адресов, запись через временный файл)
+func (c *Cassette) save() error {
+	list := make([]*Interaction, 0, len(c.interactions))
+	for _, i := range c.interactions {
+		list = append(list, i)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].URL < list[j].URL
+	})
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(c.Filename); dir != "" {
+		os.MkdirAll(dir, 0755)
+	}
+
+	tmp := c.Filename + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, c.Filename)
+}
+
+// Ключ запроса на кассете (полный url-адрес с упорядоченными параметрами)
+func CassetteKey(address string) string {
+	u, err := url.Parse(strings.TrimSpace(address))
+	if err != nil {
+		return address
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.RawQuery = u.Query().Encode()
+
+	return u.String()
+}
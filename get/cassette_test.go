@@ -0,0 +1,187 @@
+package get
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCassetteKey(t *testing.T) {
+	tests := []struct {
+		test   string
+		value  string
+		result string
+	}{
+		{"Plain", "https://t.me/s/codecamp", "https://t.me/s/codecamp"},
+		{"Query", "https://t.me/s/codecamp?before=2357", "https://t.me/s/codecamp?before=2357"},
+		{"QueryOrder", "https://t.me/s/codecamp?q=go&before=2357", "https://t.me/s/codecamp?before=2357&q=go"},
+		{"Case", "HTTPS://T.ME/s/codecamp#top", "https://t.me/s/codecamp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			result := CassetteKey(tt.value)
+
+			if result != tt.result {
+				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
+			}
+		})
+	}
+}
+
+func TestPageCassette(t *testing.T) {
+	defer EjectCassette()
+
+	var requests int64
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+
+		w.Header().Set("X-Page", r.URL.RawQuery)
+		w.Header().Set("Set-Cookie", "stel_ssid=secret")
+
+		if r.URL.Query().Get("before") == "1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, "page %s", r.URL.RawQuery)
+	}))
+	defer server.Close()
+
+	filename := filepath.Join(t.TempDir(), "cassettes", "test.json")
+
+	tests := []struct {
+		test     string
+		mode     CassetteMode
+		value    string
+		code     int
+		result   string
+		err      error
+		requests int64
+	}{
+		{"Record", CassetteRecord, server.URL + "/s/test", 200, "page ", nil, 1},
+		{"RecordBefore", CassetteRecord, server.URL + "/s/test?before=20", 200, "page before=20", nil, 2},
+		{"RecordAfter", CassetteRecord, server.URL + "/s/test?after=10", 200, "page after=10", nil, 3},
+		{"RecordNotFound", CassetteRecord, server.URL + "/s/test?before=1", 404, "", nil, 4},
+		{"Replay", CassetteReplay, server.URL + "/s/test", 200, "page ", nil, 4},
+		{"ReplayBefore", CassetteReplay, server.URL + "/s/test?before=20", 200, "page before=20", nil, 4},
+		{"ReplayAfter", CassetteReplay, server.URL + "/s/test?after=10", 200, "page after=10", nil, 4},
+		{"ReplayNotFound", CassetteReplay, server.URL + "/s/test?before=1", 404, "", nil, 4},
+		{"ReplayMiss", CassetteReplay, server.URL + "/s/test?before=30", 500, "", ErrCassetteMiss, 4},
+		{"PassthroughHit", CassettePassthrough, server.URL + "/s/test", 200, "page ", nil, 4},
+		{"PassthroughMiss", CassettePassthrough, server.URL + "/s/test?before=30", 200, "page before=30", nil, 5},
+		{"PassthroughNotRecorded", CassetteReplay, server.URL + "/s/test?before=30", 500, "", ErrCassetteMiss, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			if err := UseCassette(filename, tt.mode); err != nil {
+				t.Fatalf("Получена ошибка: %v", err)
+			}
+
+			code, result, err := Page(tt.value)
+
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Получена ошибка: %v, ожидается: %v", err, tt.err)
+			}
+			if code != tt.code {
+				t.Errorf("Получен код: %v, ожидается: %v", code, tt.code)
+			}
+			if result != tt.result {
+				t.Errorf("Получено значение: %q, ожидается: %q", result, tt.result)
+			}
+			if n := atomic.LoadInt64(&requests); n != tt.requests {
+				t.Errorf("Запросов к серверу: %v, ожидается: %v", n, tt.requests)
+			}
+		})
+	}
+
+	// Заголовки и время записи сохраняются (кроме куки)
+	c, _ := LoadCassette(filename, CassetteReplay)
+
+	i, ok := c.Find(server.URL + "/s/test?before=20")
+	if !ok {
+		t.Fatalf("Запрос не найден на кассете")
+	}
+	if i.Header.Get("X-Page") != "before=20" || i.Header.Get("Set-Cookie") != "" || i.RecordedAt.IsZero() {
+		t.Errorf("Получено значение: %+v", i)
+	}
+	if c.Len() != 4 {
+		t.Errorf("Записано запросов: %v, ожидается: %v", c.Len(), 4)
+	}
+}
+
+func TestCassetteFlush(t *testing.T) {
+	defer EjectCassette()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "page %s", r.URL.RawQuery)
+	}))
+	defer server.Close()
+
+	filename := filepath.Join(t.TempDir(), "test.json")
+
+	if err := UseCassette(filename, CassetteRecord); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	// Запросы записываются в память, файл - только при сохранении
+	for _, query := range []string{"", "?before=20", "?before=10"} {
+		Page(server.URL + "/s/test" + query)
+	}
+
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Fatalf("Файл кассеты записан до сохранения: %v", err)
+	}
+
+	if err := EjectCassette(); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	c, err := LoadCassette(filename, CassetteReplay)
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	if c.Len() != 3 {
+		t.Errorf("Записано запросов: %v, ожидается: %v", c.Len(), 3)
+	}
+}
+
+func TestUseCassette(t *testing.T) {
+	defer EjectCassette()
+
+	tests := []struct {
+		test     string
+		filename string
+		mode     CassetteMode
+		isError  bool
+	}{
+		{"Fixture", "data/cassettes/codecamp.json", CassetteReplay, false},
+		{"ReplayNotExists", "data/cassettes/not_exists.json", CassetteReplay, true},
+		{"RecordNotExists", "data/cassettes/not_exists.json", CassetteRecord, false},
+		{"Broken", "data/pages/info/codecamp", CassetteReplay, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			err := UseCassette(tt.filename, tt.mode)
+
+			if (err != nil) != tt.isError {
+				t.Errorf("Получена ошибка: %v, ожидается: %v", err, tt.isError)
+			}
+		})
+	}
+
+	// Без кассеты страница не получается
+	EjectCassette()
+	SetTransport("cassette")
+
+	if _, _, err := Page("https://t.me/s/codecamp"); err == nil {
+		t.Errorf("Ошибка не получена")
+	}
+}
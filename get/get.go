@@ -3,51 +3,177 @@ package get
 import (
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net/http"
-	"net/url"
 	"os/exec"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"statosphere/parser/check"
 	"statosphere/parser/file"
+	"statosphere/parser/flight"
 	"statosphere/parser/proxy"
 )
 
 var (
-	CurlFasterThreshold = 50    // Предел запросов, до которого curl может оказаться быстрее http
-	IsCacheDisable      = false // Отключение кэша
+	IsCacheDisable = false // Отключение кэша
+	IsCoalescing   = true  // Объединение одновременных запросов одной страницы
 )
 
+// Результат запроса страницы
+type page struct {
+	code int
+	body string
+}
+
+// Одновременные запросы страниц (по url-адресу)
+var pages flight.Group[page]
+
 // Получение страницы (оптимальным способом)
 func Page(address string) (int, string, error) {
-	proxy := proxy.Cooldown()
+	// Ключ для выбора прокси (страницы одного канала можно запрашивать с одного адреса)
+	key := Key(address)
+
+	if Transport() == "file" {
+		return PageFile(address, proxy.Select(key))
+	}
+
+	if !IsCoalescing {
+		return pageTransport(address, key)
+	}
+
+	// Одновременные запросы одной страницы получают один ответ (и занимают один прокси)
+	res, err, _ := pages.Do(address, func() (page, error) {
+		code, body, err := pageTransport(address, key)
+		return page{code, body}, err
+	})
+
+	return res.code, res.body, err
+}
+
+// Получение страницы через текущий сетевой транспорт или кассету
+func pageTransport(address, key string) (int, string, error) {
+	if Transport() == "cassette" {
+		return PageCassette(address)
+	}
+
+	code, _, body, err := pageNetwork(address, key)
+
+	return code, body, err
+}
+
+// Получение страницы из сети (с заголовками ответа, если они доступны)
+func pageNetwork(address, key string) (int, http.Header, string, error) {
+	// Прокси с учетом стратегии и лимитов запросов
+	proxyAddr, err := proxy.Take(key)
+	if err != nil {
+		return http.StatusTooManyRequests, nil, "", err
+	}
+
+	var (
+		start  = time.Now()
+		code   int
+		header http.Header
+		body   string
+	)
 
 	switch Transport() {
-	case "http":
-		fallthrough
-	default:
-		return PageHTTP(address, proxy)
 	case "curl":
-		return PageCURL(address, proxy)
-	case "file":
-		return PageFile(address, proxy)
+		code, body, err = PageCURL(address, proxyAddr)
+	default:
+		code, header, body, err = pageHTTP(address, proxyAddr)
+	}
+
+	// Учет состояния прокси
+	if strings.Contains(address, "t.me/") {
+		proxy.Record(proxyAddr, time.Since(start), IsSoftNotFound(code, body), Failure(code, err))
+	}
+
+	return code, header, body, err
+}
+
+// Ключ запроса (имя или инвайт канала)
+func Key(address string) string {
+	username, joinchat, _ := check.Username(address, true)
+	if username != "" {
+		return username
+	}
+
+	return joinchat
+}
+
+// Проверка "мягкой" 404 (Телеграм отдает пустую страницу, как будто канала нет)
+func IsSoftNotFound(code int, body string) bool {
+	if code == http.StatusNotFound {
+		return true
+	}
+
+	return code == http.StatusOK && body != "" &&
+		!strings.Contains(body, "tgme_page_title") &&
+		!strings.Contains(body, "tgme_page_extra") &&
+		!strings.Contains(body, "tgme_header_title")
+}
+
+// Ошибка запроса с учетом кода ответа
+func Failure(code int, err error) error {
+	if err != nil {
+		return err
+	}
+
+	if code != http.StatusOK && code != http.StatusNotFound {
+		return fmt.Errorf("код ответа %d", code)
+	}
+
+	return nil
+}
+
+// Функция проверки прокси запросом к странице (при нескольких адресах - по очереди)
+func Probe(addresses ...string) proxy.ProbeFunc {
+	var n uint64
+
+	return func(proxyAddr string) (time.Duration, bool, error) {
+		if len(addresses) == 0 {
+			return 0, false, errors.New("url-адрес пуст")
+		}
+
+		address := addresses[(atomic.AddUint64(&n, 1)-1)%uint64(len(addresses))]
+
+		start := time.Now()
+		code, body, err := PageHTTP(address, proxyAddr)
+
+		return time.Since(start), IsSoftNotFound(code, body), Failure(code, err)
 	}
 }
 
 // Получение страницы через пакет http
 func PageHTTP(address, proxy string) (int, string, error) {
+	code, _, body, err := pageHTTP(address, proxy)
+
+	return code, body, err
+}
+
+// Получение страницы через пакет http (с заголовками ответа)
+func pageHTTP(address, proxy string) (int, http.Header, string, error) {
 	var code = 500
 
 	if address == "" {
-		return code, "", errors.New("url-адрес пуст")
+		return code, nil, "", errors.New("url-адрес пуст")
 	}
 
-	req, _ := http.NewRequest("GET", address, nil)
+	req, err := http.NewRequest("GET", address, nil)
+	if err != nil {
+		return code, nil, "", err
+	}
 
-	client := &http.Client{}
+	// Транспорт (общий для всех запросов через один прокси)
+	transport, err := Pool(proxy)
+	if err != nil {
+		return code, nil, "", err
+	}
+
+	client := &http.Client{Transport: transport}
 
 	// Таймаут
 	timeout := Timeout()
@@ -56,22 +182,8 @@ func PageHTTP(address, proxy string) (int, string, error) {
 		client.Timeout = timeout
 	}
 
-	// Прокси
-
-	if proxy != "" {
-		proxyUrl, err := url.ParseRequestURI(proxy)
-		if err != nil {
-			return code, "", fmt.Errorf("url-адрес прокси %q не валиден", proxy)
-		}
-
-		client.Transport = &http.Transport{Proxy: http.ProxyURL(proxyUrl)}
-	}
-
-	// Отключение кэша
-	if IsCacheDisable {
-		req.Header.Set("Cache-Control", "no-cache")
-		req.Header.Set("Pragma", "no-cache")
-	}
+	// Заголовки профиля (сжатие и отключение кэша - там же)
+	req.Header = RequestHeaders()
 
 	// Запрос
 
@@ -80,13 +192,32 @@ func PageHTTP(address, proxy string) (int, string, error) {
 		code = resp.StatusCode
 	}
 	if err != nil {
-		return code, "", err
+		return code, nil, "", err
 	}
 	defer resp.Body.Close()
 
-	body, _ := ioutil.ReadAll(resp.Body)
+	body, err := ReadBody(resp.Header.Get("Content-Encoding"), resp.Body)
+	if err != nil {
+		return code, resp.Header, "", err
+	}
+
+	return code, resp.Header, body, nil
+}
+
+// Заголовки запроса для curl (без Accept-Encoding - способы сжатия задает --compressed,
+// иначе curl без поддержки br получит ответ, который не сможет распаковать)
+func curlHeaders(header http.Header) []string {
+	var params []string
+
+	for name, values := range header {
+		if name == "Accept-Encoding" {
+			continue
+		}
+
+		params = append(params, "-H", name+": "+values[0])
+	}
 
-	return code, string(body), nil
+	return params
 }
 
 // Получение страницы через внешний Curl
@@ -99,8 +230,17 @@ func PageCURL(address, proxy string) (int, string, error) {
 
 	var params []string
 
-	// Игнорирование ssl-сертификатов
-	params = append(params, "-k")
+	// Проверка сертификатов (как в http)
+	if !IsTLSVerify {
+		params = append(params, "-k")
+	}
+	if CACertFile != "" {
+		params = append(params, "--cacert")
+		params = append(params, CACertFile)
+	}
+
+	// Следование перенаправлениям (как в http)
+	params = append(params, "-L")
 
 	// Таймаут
 	timeout := TimeoutString()
@@ -110,19 +250,24 @@ func PageCURL(address, proxy string) (int, string, error) {
 		params = append(params, timeout)
 	}
 
-	// Прокси
-	if proxy != "" {
+	// Прокси (учетные данные передаются через stdin, чтобы не светить их в списке процессов)
+	proxyAddr, config := curlProxy(proxy)
+
+	if proxyAddr != "" {
 		params = append(params, "-x")
-		params = append(params, proxy)
+		params = append(params, proxyAddr)
+	}
+	if config != "" {
+		params = append(params, "-K")
+		params = append(params, "-")
 	}
 
-	// Отключение кэша
-	if IsCacheDisable {
-		params = append(params, "-H")
-		params = append(params, "Cache-Control: no-cache")
+	// Заголовки профиля (отключение кэша - там же)
+	params = append(params, curlHeaders(RequestHeaders())...)
 
-		params = append(params, "-H")
-		params = append(params, "Pragma: no-cache")
+	// Сжатие (curl сам запрашивает поддерживаемые им способы и распаковывает)
+	if IsCompression {
+		params = append(params, "--compressed")
 	}
 
 	params = append(params, address)
@@ -130,9 +275,32 @@ func PageCURL(address, proxy string) (int, string, error) {
 	// Запрос
 
 	curl := exec.Command("curl", params...)
+	if config != "" {
+		curl.Stdin = strings.NewReader(config)
+	}
 
-	body, err := curl.Output()
+	stdout, err := curl.StdoutPipe()
 	if err != nil {
+		return code, "", err
+	}
+	if err := curl.Start(); err != nil {
+		return code, "", err
+	}
+
+	// Предельный размер ответа после распаковки (как в http)
+	var reader io.Reader = stdout
+	if MaxBodySize > 0 {
+		reader = io.LimitReader(stdout, MaxBodySize+1)
+	}
+
+	body, _ := io.ReadAll(reader)
+	if MaxBodySize > 0 && int64(len(body)) > MaxBodySize {
+		curl.Process.Kill()
+		curl.Wait()
+		return code, "", fmt.Errorf("%w (%d байт)", ErrBodyTooLarge, MaxBodySize)
+	}
+
+	if err := curl.Wait(); err != nil {
 		switch e := err.(type) {
 		case *exec.ExitError:
 			switch e.ExitCode() {
@@ -140,6 +308,8 @@ func PageCURL(address, proxy string) (int, string, error) {
 				err = fmt.Errorf("превышен таймаут (%v сек.)", timeout)
 			case 5, 56:
 				err = errors.New("прокси невалиден (недоступен)")
+			case 60:
+				err = errors.New("сертификат сервера не прошел проверку")
 			}
 		}
 		return code, "", err
@@ -166,8 +336,11 @@ func PageFile(address, proxy string) (int, string, error) {
 	}
 
 	username, joinchat, _ := check.Username(address, true)
+	slug := check.Addlist(address)
 
 	switch {
+	case slug != "":
+		path = "data/pages/addlist/" + slug
 	case username != "":
 		path += username
 	case joinchat != "":
@@ -199,19 +372,17 @@ type options struct {
 // Объект опций
 var o = options{transport: "http", timeout: 15 * time.Second}
 
-// Запись нового значения транспорта
-func SetTransport(transport string, requests int) {
+// Запись нового значения транспорта (неизвестный - http)
+func SetTransport(transport string) {
 	o.m.Lock()
 	defer o.m.Unlock()
 
 	transport = strings.ToLower(transport)
 
 	o.transport = "http"
-	switch {
-	case transport == "curl" && requests <= CurlFasterThreshold:
-		o.transport = "curl"
-	case transport == "file":
-		o.transport = "file"
+	switch transport {
+	case "curl", "file", "cassette":
+		o.transport = transport
 	}
 }
 
@@ -1,8 +1,14 @@
 package get
 
 import (
+	"compress/gzip"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -29,7 +35,7 @@ func TestPage(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.test, func(t *testing.T) {
-			SetTransport(tt.transport, 0)
+			SetTransport(tt.transport)
 
 			code, result, err := Page(tt.value)
 
@@ -46,8 +52,63 @@ func TestPage(t *testing.T) {
 	}
 }
 
+func TestPageCoalescing(t *testing.T) {
+	defer func() { IsCoalescing = true }()
+
+	var requests int64
+
+	// Медленный сервер, чтобы запросы пересеклись
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		time.Sleep(200 * time.Millisecond)
+		res.Write([]byte("tgme_page_title"))
+	}))
+	defer server.Close()
+
+	SetTransport("http")
+	SetTimeout(5 * time.Second)
+
+	tests := []struct {
+		test         string
+		isCoalescing bool
+		count        int
+		requests     int64
+	}{
+		{"Coalescing", true, 10, 1},
+		{"NoCoalescing", false, 10, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			IsCoalescing = tt.isCoalescing
+			atomic.StoreInt64(&requests, 0)
+
+			var wg sync.WaitGroup
+
+			for i := 0; i < tt.count; i++ {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					code, result, err := Page(server.URL + "/s/coalescing")
+
+					if err != nil || code != 200 || result != "tgme_page_title" {
+						t.Errorf("Получено значение: %v %q (%v)", code, result, err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if n := atomic.LoadInt64(&requests); n != tt.requests {
+				t.Errorf("Запросов к серверу: %v, ожидается: %v", n, tt.requests)
+			}
+		})
+	}
+}
+
 func TestPageHTTP(t *testing.T) {
-	SetTransport("http", 0)
+	SetTransport("http")
 	IsCacheDisable = true
 
 	tests := []struct {
@@ -85,7 +146,7 @@ func TestPageHTTP(t *testing.T) {
 }
 
 func TestPageCURL(t *testing.T) {
-	SetTransport("curl", 0)
+	SetTransport("curl")
 	IsCacheDisable = true
 
 	tests := []struct {
@@ -123,8 +184,60 @@ func TestPageCURL(t *testing.T) {
 	}
 }
 
+func TestCurlHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("Accept-Encoding", AcceptEncoding)
+	header.Set("Cache-Control", "no-cache")
+
+	result := curlHeaders(header)
+	expected := []string{"-H", "Cache-Control: no-cache"}
+
+	if strings.Join(result, " ") != strings.Join(expected, " ") {
+		t.Errorf("Получено значение: %v, ожидается: %v", result, expected)
+	}
+}
+
+func TestPageCURLMaxBody(t *testing.T) {
+	defer func(size int64) { MaxBodySize = size }(MaxBodySize)
+	defer SetTimeout(0)
+
+	// Сжатый ответ меньше предела, распакованный - больше
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte(strings.Repeat("a", 4096)))
+		gz.Close()
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		test    string
+		value   int64
+		isError bool
+	}{
+		{"Fits", 4096, false},
+		{"TooLarge", 1024, true},
+		{"Unlimited", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			MaxBodySize = tt.value
+
+			_, result, err := PageCURL(server.URL, "")
+
+			if tt.isError != errors.Is(err, ErrBodyTooLarge) {
+				t.Fatalf("Получена ошибка: %v, ожидается: %v", err, tt.isError)
+			}
+			if !tt.isError && len(result) != 4096 {
+				t.Errorf("Получено байт: %v, ожидается: %v", len(result), 4096)
+			}
+		})
+	}
+}
+
 func TestPageFile(t *testing.T) {
-	SetTransport("file", 0)
+	SetTransport("file")
 
 	tests := []struct {
 		test    string
@@ -137,6 +250,7 @@ func TestPageFile(t *testing.T) {
 		{"Joinchat", "https://t.me/+so8YUpEsL4BkZGQy", "", 200, false},
 		{"Remove", "https://t.me/serious_tester", "data/pages/info/serious_tester", 200, false},
 		{"Messages", "https://t.me/s/thecodemedia", "", 200, false},
+		{"Addlist", "https://t.me/addlist/ITchannels2023", "", 200, false},
 		{"Wrong", "***", "", 500, true},
 		{"Empty", "", "", 500, true},
 	}
@@ -165,27 +279,22 @@ func TestPageFile(t *testing.T) {
 }
 
 func TestSetTransport(t *testing.T) {
-	CurlFasterThreshold = 10
-
 	tests := []struct {
 		test   string
 		value  string
-		count  int
 		result string
 	}{
-		{"Http", "Http", 0, "http"},
-		{"HttpHigh", "http", 1000, "http"},
-		{"CurlFaster", "Curl", 5, "curl"},
-		{"CurlSlower", "CURL", 15, "http"},
-		{"File", "file", 0, "file"},
-		{"FileHigh", "FiLe", 500, "file"},
-		{"Wrong", "tcp", 0, "http"},
-		{"Empty", "", 0, "http"},
+		{"Http", "Http", "http"},
+		{"Curl", "CURL", "curl"},
+		{"File", "FiLe", "file"},
+		{"Cassette", "Cassette", "cassette"},
+		{"Wrong", "tcp", "http"},
+		{"Empty", "", "http"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.test, func(t *testing.T) {
-			SetTransport(tt.value, tt.count)
+			SetTransport(tt.value)
 			result := o.transport
 
 			if result != tt.result {
@@ -208,7 +317,7 @@ func TestTransport(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.test, func(t *testing.T) {
-			SetTransport(tt.value, 0)
+			SetTransport(tt.value)
 			result := Transport()
 
 			if result != tt.result {
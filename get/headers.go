@@ -0,0 +1,202 @@
+package get
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+var (
+	IsCompression       = true     // Запрос сжатых ответов (gzip, deflate, br)
+	MaxBodySize   int64 = 10 << 20 // Предельный размер ответа после распаковки (0 - без ограничения)
+)
+
+// Ошибка превышения размера ответа
+var ErrBodyTooLarge = errors.New("размер ответа превышает предел")
+
+// Поддерживаемые сжатия
+const AcceptEncoding = "gzip, deflate, br"
+
+// Профиль заголовков запроса
+type Profile struct {
+	UserAgents     []string          // Строки User-Agent (по очереди)
+	Accept         string            // Заголовок Accept
+	AcceptLanguage string            // Заголовок Accept-Language
+	Header         map[string]string // Дополнительные заголовки
+}
+
+// Встроенные профили
+var Profiles = map[string]Profile{
+	"desktop": {
+		UserAgents: []string{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15",
+			"Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0",
+		},
+		Accept:         "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+		AcceptLanguage: "en-US,en;q=0.9",
+	},
+	"mobile": {
+		UserAgents: []string{
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+			"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Mobile Safari/537.36",
+		},
+		Accept:         "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+		AcceptLanguage: "en-US,en;q=0.9",
+	},
+	"none": {},
+}
+
+// Текущий профиль
+type profiles struct {
+	name    string
+	profile Profile
+	n       int
+	m       sync.Mutex
+}
+
+// Объект текущего профиля
+var hp = profiles{name: "desktop", profile: Profiles["desktop"]}
+
+// Выбор профиля заголовков по названию
+func SetProfile(name string) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	profile, ok := Profiles[name]
+	if !ok {
+		return fmt.Errorf("профиль заголовков %q не поддерживается", name)
+	}
+
+	hp.m.Lock()
+	defer hp.m.Unlock()
+
+	hp.name = name
+	hp.profile = profile
+	hp.n = 0
+
+	return nil
+}
+
+// Получение названия текущего профиля
+func ProfileName() string {
+	hp.m.Lock()
+	defer hp.m.Unlock()
+
+	return hp.name
+}
+
+// Заголовки очередного запроса (User-Agent - по очереди)
+func RequestHeaders() http.Header {
+	hp.m.Lock()
+	profile := hp.profile
+	n := hp.n
+	hp.n++
+	hp.m.Unlock()
+
+	header := make(http.Header)
+
+	if len(profile.UserAgents) > 0 {
+		header.Set("User-Agent", profile.UserAgents[n%len(profile.UserAgents)])
+	}
+	if profile.Accept != "" {
+		header.Set("Accept", profile.Accept)
+	}
+	if profile.AcceptLanguage != "" {
+		header.Set("Accept-Language", profile.AcceptLanguage)
+	}
+	for name, value := range profile.Header {
+		header.Set(name, value)
+	}
+
+	// Сжатие
+	if IsCompression {
+		header.Set("Accept-Encoding", AcceptEncoding)
+	}
+
+	// Отключение кэша
+	if IsCacheDisable {
+		header.Set("Cache-Control", "no-cache")
+		header.Set("Pragma", "no-cache")
+	}
+
+	return header
+}
+
+// Распаковка ответа по заголовку Content-Encoding (несколько сжатий - в обратном порядке)
+func Decode(encoding string, body io.Reader) (io.Reader, error) {
+	encodings := strings.Split(encoding, ",")
+
+	for i := len(encodings) - 1; i >= 0; i-- {
+		var err error
+
+		switch strings.ToLower(strings.TrimSpace(encodings[i])) {
+		case "", "identity":
+		case "gzip", "x-gzip":
+			body, err = gzip.NewReader(body)
+		case "deflate":
+			body, err = inflate(body)
+		case "br":
+			body = brotli.NewReader(body)
+		default:
+			err = fmt.Errorf("сжатие %q не поддерживается", encodings[i])
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return body, nil
+}
+
+// Распаковка deflate (zlib или, как у части серверов, "голый" deflate)
+func inflate(body io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(body)
+
+	header, err := buffered.Peek(2)
+	switch {
+	case err == io.EOF && len(header) == 0:
+		return buffered, nil
+	case err != nil:
+		return nil, err
+	}
+
+	// Заголовок zlib: CM = 8, (CMF*256 + FLG) кратно 31
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+
+	return flate.NewReader(buffered), nil
+}
+
+// Чтение ответа с распаковкой и ограничением размера
+func ReadBody(encoding string, body io.Reader) (string, error) {
+	reader, err := Decode(encoding, body)
+	if err != nil {
+		return "", err
+	}
+
+	if MaxBodySize > 0 {
+		reader = io.LimitReader(reader, MaxBodySize+1)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+
+	if MaxBodySize > 0 && int64(len(data)) > MaxBodySize {
+		return "", fmt.Errorf("%w (%d байт)", ErrBodyTooLarge, MaxBodySize)
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,259 @@
+package get
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Сжатие строки
+func compress(encoding, text string) []byte {
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+	)
+
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "rawdeflate":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	default:
+		return []byte(text)
+	}
+
+	w.Write([]byte(text))
+	w.Close()
+
+	return buf.Bytes()
+}
+
+func TestSetProfile(t *testing.T) {
+	defer SetProfile("desktop")
+
+	tests := []struct {
+		test    string
+		value   string
+		result  string
+		isError bool
+	}{
+		{"Desktop", "desktop", "desktop", false},
+		{"Mobile", " Mobile ", "mobile", false},
+		{"None", "none", "none", false},
+		{"Unknown", "bot", "none", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			err := SetProfile(tt.value)
+
+			if (err != nil) != tt.isError {
+				t.Fatalf("Получена ошибка: %v, ожидается: %v", err, tt.isError)
+			}
+			if result := ProfileName(); result != tt.result {
+				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
+			}
+		})
+	}
+}
+
+func TestRequestHeaders(t *testing.T) {
+	defer SetProfile("desktop")
+	defer func() { IsCompression, IsCacheDisable = true, false }()
+
+	SetProfile("desktop")
+	IsCompression, IsCacheDisable = true, false
+
+	agents := Profiles["desktop"].UserAgents
+
+	// User-Agent по очереди
+	for i := 0; i < len(agents)+1; i++ {
+		header := RequestHeaders()
+
+		if result := header.Get("User-Agent"); result != agents[i%len(agents)] {
+			t.Errorf("User-Agent - получено значение: %q, ожидается: %q", result, agents[i%len(agents)])
+		}
+		if result := header.Get("Accept-Language"); result == "" {
+			t.Errorf("Accept-Language не задан")
+		}
+		if result := header.Get("Accept-Encoding"); result != AcceptEncoding {
+			t.Errorf("Accept-Encoding - получено значение: %q, ожидается: %q", result, AcceptEncoding)
+		}
+	}
+
+	SetProfile("none")
+	IsCompression, IsCacheDisable = false, true
+
+	header := RequestHeaders()
+	if len(header) != 2 || header.Get("Cache-Control") != "no-cache" {
+		t.Errorf("Получено значение: %v, ожидаются только заголовки кэша", header)
+	}
+}
+
+func TestReadBody(t *testing.T) {
+	defer func(size int64) { MaxBodySize = size }(MaxBodySize)
+
+	text := strings.Repeat("tgme_page_title ", 100)
+
+	tests := []struct {
+		test     string
+		encoding string
+		body     []byte
+		max      int64
+		result   string
+		err      error
+		isError  bool
+	}{
+		{"Identity", "", compress("", text), 0, text, nil, false},
+		{"Gzip", "gzip", compress("gzip", text), 0, text, nil, false},
+		{"Deflate", "deflate", compress("deflate", text), 0, text, nil, false},
+		{"RawDeflate", "deflate", compress("rawdeflate", text), 0, text, nil, false},
+		{"Brotli", "br", compress("br", text), 0, text, nil, false},
+		{"Chain", "deflate, gzip", compress("gzip", string(compress("deflate", text))), 0, text, nil, false},
+		{"EmptyDeflate", "deflate", nil, 0, "", nil, false},
+		{"Limit", "", compress("", text), int64(len(text)), text, nil, false},
+		{"TooLarge", "", compress("", text), int64(len(text) - 1), "", ErrBodyTooLarge, true},
+		{"TooLargeUnpacked", "gzip", compress("gzip", text), 100, "", ErrBodyTooLarge, true},
+		{"Unknown", "zstd", compress("", text), 0, "", nil, true},
+		{"Broken", "gzip", []byte("not gzip"), 0, "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			MaxBodySize = tt.max
+
+			result, err := ReadBody(tt.encoding, bytes.NewReader(tt.body))
+
+			if (err != nil) != tt.isError {
+				t.Fatalf("Получена ошибка: %v, ожидается: %v", err, tt.isError)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("Получена ошибка: %v, ожидается: %v", err, tt.err)
+			}
+			if result != tt.result {
+				t.Errorf("Получено значение: %q, ожидается: %q", result, tt.result)
+			}
+		})
+	}
+}
+
+func TestPageHeaders(t *testing.T) {
+	defer func(size int64) { MaxBodySize = size }(MaxBodySize)
+	defer SetProfile("desktop")
+
+	server, _ := newTestServer(t)
+	SetProfile("mobile")
+	SetTimeout(5 * time.Second)
+
+	// Сервер сжимает ответ тем, что предложил клиент, и возвращает его заголовки
+	server.Config.Handler = http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		text := "UA=" + req.Header.Get("User-Agent") + "\nAL=" + req.Header.Get("Accept-Language") +
+			"\n" + strings.Repeat("tgme_page_title ", 100)
+
+		encoding := req.URL.Query().Get("encoding")
+		if encoding != "" && strings.Contains(req.Header.Get("Accept-Encoding"), encoding) {
+			res.Header().Set("Content-Encoding", encoding)
+		} else {
+			encoding = ""
+		}
+
+		res.Write(compress(encoding, text))
+	})
+
+	tests := []struct {
+		test    string
+		query   string
+		max     int64
+		err     error
+		isError bool
+	}{
+		{"Plain", "", 10 << 20, nil, false},
+		{"Gzip", "?encoding=gzip", 10 << 20, nil, false},
+		{"Deflate", "?encoding=deflate", 10 << 20, nil, false},
+		{"Brotli", "?encoding=br", 10 << 20, nil, false},
+		{"TooLarge", "?encoding=gzip", 1000, ErrBodyTooLarge, true},
+	}
+
+	pages := map[string]func(string, string) (int, string, error){"Http": PageHTTP, "Curl": PageCURL}
+
+	for name, page := range pages {
+		for _, tt := range tests {
+			t.Run(name+tt.test, func(t *testing.T) {
+				MaxBodySize = tt.max
+
+				_, result, err := page(server.URL+tt.query, "")
+
+				if (err != nil) != tt.isError {
+					t.Fatalf("Получена ошибка: %v, ожидается: %v", err, tt.isError)
+				}
+				if tt.err != nil && !errors.Is(err, tt.err) {
+					t.Errorf("Получена ошибка: %v, ожидается: %v", err, tt.err)
+				}
+				if tt.isError {
+					return
+				}
+
+				if !strings.Contains(result, "UA=Mozilla/5.0") || !strings.Contains(result, "AL=en-US") {
+					t.Errorf("Заголовки профиля не переданы: %q", result[:100])
+				}
+				if !strings.HasSuffix(result, "tgme_page_title ") {
+					t.Errorf("Ответ не распакован: %q", result[:100])
+				}
+			})
+		}
+	}
+}
+
+func TestPageTLSVerify(t *testing.T) {
+	defer func() { IsTLSVerify = true; ResetPools() }()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Write([]byte("tgme_page_title"))
+	}))
+	defer server.Close()
+
+	SetTimeout(5 * time.Second)
+
+	tests := []struct {
+		test     string
+		isVerify bool
+		isError  bool
+	}{
+		{"Verify", true, true},
+		{"NoVerify", false, false},
+	}
+
+	pages := map[string]func(string, string) (int, string, error){"Http": PageHTTP, "Curl": PageCURL}
+
+	for name, page := range pages {
+		for _, tt := range tests {
+			t.Run(name+tt.test, func(t *testing.T) {
+				IsTLSVerify = tt.isVerify
+				ResetPools()
+
+				_, result, err := page(server.URL, "")
+
+				if (err != nil) != tt.isError {
+					t.Fatalf("Получена ошибка: %v, ожидается: %v", err, tt.isError)
+				}
+				if !tt.isError && result != "tgme_page_title" {
+					t.Errorf("Получено значение: %q", result)
+				}
+			})
+		}
+	}
+}
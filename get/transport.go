@@ -0,0 +1,166 @@
+package get
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"statosphere/parser/proxy"
+)
+
+var (
+	DialTimeout         = 5 * time.Second  // Таймаут установки соединения
+	KeepAlive           = 30 * time.Second // Период keep-alive для открытых соединений
+	TLSHandshakeTimeout = 5 * time.Second  // Таймаут TLS-рукопожатия
+	IdleConnTimeout     = 90 * time.Second // Время жизни простаивающего соединения
+	MaxIdleConns        = 256              // Предел простаивающих соединений (на транспорт)
+	MaxIdleConnsPerHost = 64               // Предел простаивающих соединений на хост
+	TLSClientConfig     *tls.Config        // Настройки TLS (nil - по умолчанию)
+	IsTLSVerify         = true             // Проверка сертификатов (в http и curl; после изменения - ResetPools)
+	CACertFile          = ""               // Файл с корневыми сертификатами в PEM (в http и curl)
+)
+
+// Набор долгоживущих транспортов (по одному на прокси)
+type transports struct {
+	t map[string]*http.Transport
+	m sync.RWMutex
+}
+
+// Объект транспортов
+var ts = transports{t: make(map[string]*http.Transport)}
+
+// Получение транспорта для прокси (с созданием при отсутствии)
+func Pool(proxyAddr string) (*http.Transport, error) {
+	ts.m.RLock()
+	t, ok := ts.t[proxyAddr]
+	ts.m.RUnlock()
+
+	if ok {
+		return t, nil
+	}
+
+	ts.m.Lock()
+	defer ts.m.Unlock()
+
+	if t, ok := ts.t[proxyAddr]; ok {
+		return t, nil
+	}
+
+	t, err := NewTransport(proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	ts.t[proxyAddr] = t
+
+	return t, nil
+}
+
+// Создание транспорта (keep-alive, HTTP/2 при возможности)
+func NewTransport(proxyAddr string) (*http.Transport, error) {
+	dialer := &net.Dialer{
+		Timeout:   DialTimeout,
+		KeepAlive: KeepAlive,
+	}
+
+	t := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          MaxIdleConns,
+		MaxIdleConnsPerHost:   MaxIdleConnsPerHost,
+		IdleConnTimeout:       IdleConnTimeout,
+		TLSHandshakeTimeout:   TLSHandshakeTimeout,
+		ExpectContinueTimeout: time.Second,
+		DisableCompression:    true, // Сжатие запрашивается и распаковывается явно (см. headers.go)
+	}
+
+	if TLSClientConfig != nil {
+		t.TLSClientConfig = TLSClientConfig.Clone()
+	}
+
+	// Проверка сертификатов
+	if !IsTLSVerify || CACertFile != "" {
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{}
+		}
+
+		t.TLSClientConfig.InsecureSkipVerify = !IsTLSVerify
+	}
+
+	if CACertFile != "" {
+		pem, err := os.ReadFile(CACertFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("в файле %s нет сертификатов", CACertFile)
+		}
+
+		t.TLSClientConfig.RootCAs = pool
+	}
+
+	if proxyAddr != "" {
+		proxyUrl, err := url.ParseRequestURI(proxyAddr)
+		if err != nil {
+			return nil, fmt.Errorf("url-адрес прокси %q не валиден", proxy.Redact(proxyAddr))
+		}
+
+		t.Proxy = http.ProxyURL(proxyUrl)
+	}
+
+	return t, nil
+}
+
+// Число транспортов в наборе
+func Pools() int {
+	ts.m.RLock()
+	defer ts.m.RUnlock()
+
+	return len(ts.t)
+}
+
+// Закрытие простаивающих соединений
+func CloseIdle() {
+	ts.m.RLock()
+	defer ts.m.RUnlock()
+
+	for _, t := range ts.t {
+		t.CloseIdleConnections()
+	}
+}
+
+// Сброс набора транспортов (например, после смены настроек)
+func ResetPools() {
+	ts.m.Lock()
+	defer ts.m.Unlock()
+
+	for _, t := range ts.t {
+		t.CloseIdleConnections()
+	}
+
+	ts.t = make(map[string]*http.Transport)
+}
+
+// Адрес прокси для curl и конфиг с учетными данными (передается через stdin,
+// чтобы логин и пароль не попадали в список процессов)
+func curlProxy(proxyAddr string) (address, config string) {
+	if proxyAddr == "" {
+		return "", ""
+	}
+
+	address, user, password := proxy.Credentials(proxyAddr)
+	if user != "" {
+		config = fmt.Sprintf("proxy-user = %q\n", user+":"+password)
+	}
+
+	return address, config
+}
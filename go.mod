@@ -1,3 +1,5 @@
 module statosphere/parser
 
 go 1.20
+
+require github.com/andybalholm/brotli v1.1.0
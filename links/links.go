@@ -140,3 +140,15 @@ func (l Links) Remove(link string) {
 func (l *Links) Clear() {
 	*l = make(Links)
 }
+
+// Примерный объем ссылок в памяти (в байтах)
+func (l Links) Size() int64 {
+	var size int64
+	for key, data := range l {
+		size += int64(len(key)+len(data.Link)) + int64(len(data.Posts))*8 + 64
+		for _, caption := range data.Captions {
+			size += int64(len(caption)) + 16
+		}
+	}
+	return size
+}
@@ -2,18 +2,25 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"statosphere/parser/cache"
+	"statosphere/parser/check"
+	"statosphere/parser/export"
 	"statosphere/parser/file"
 	"statosphere/parser/format"
 	"statosphere/parser/get"
 	"statosphere/parser/page"
 	"statosphere/parser/parse"
 	"statosphere/parser/proxy"
+	"statosphere/parser/storage"
 )
 
 func main() {
@@ -25,24 +32,62 @@ func main() {
 	// Параметры
 
 	var (
-		mode, peer, peers, server                     string
-		offset, limit, messages, port                 uint
+		mode, peer, peers, folder, server, strategy   string
+		exportPath, output, proxies, proxiesURL       string
+		cassette, cassetteMode, profile, cacheFile    string
+		cacheRedis, adminToken, storeDir              string
+		rates, ratePolicy                             string
+		offset, limit, messages, port, benchRequests  uint
+		refresh                                       uint
 		isConsole, isServer, isProxy, isExact, isTest bool
+		isJSON, isRewrite, isInsecure                 bool
+		cacheEntries                                  int
+		maxBody, cacheSize                            int64
+		cacheTTL, cacheStaleTTL, cacheSave            time.Duration
+		snapshotEvery, rateWait                       time.Duration
 	)
 
-	flag.StringVar(&mode, "mode", "server", "режим работы (console, server)")
+	flag.StringVar(&mode, "mode", "server", "режим работы (console, server, import, sync, proxy)")
 	flag.BoolVar(&isConsole, "console", false, "режим работы в консоле")
 	flag.BoolVar(&isServer, "server", false, "режим работы в виде сервера")
 	flag.StringVar(&server, "address", "localhost", "адрес сервера")
 	flag.UintVar(&port, "port", 8080, "порт сервера")
 	flag.BoolVar(&isProxy, "proxy", true, "включение прокси")
+	flag.StringVar(&proxies, "proxies", "data/proxies", "файл со списком прокси (перечитывается при изменении)")
+	flag.StringVar(&proxiesURL, "proxies-url", "", "url-адрес со списком прокси")
+	flag.StringVar(&strategy, "strategy", proxy.StrategyCooldown, "стратегия выбора прокси (cooldown, round-robin, lru, weighted, sticky, direct-first)")
+	flag.StringVar(&rates, "rates", proxy.DefaultRates, "лимиты запросов на исходящий адрес (класс=в минуту/всплеск через запятую, классы: direct, proxy или схема прокси, пусто - без лимитов)")
+	flag.StringVar(&ratePolicy, "rate-policy", "wait", "политика при исчерпании лимитов всех адресов (wait, fail-fast)")
+	flag.DurationVar(&rateWait, "rate-wait", 30*time.Second, "предельное ожидание освобождения лимита при политике wait")
 	flag.StringVar(&peer, "channel", "", "адрес канала")
 	flag.StringVar(&peers, "channels", "", "адреса каналов (через запятую)")
+	flag.StringVar(&folder, "folder", "", "адрес папки каналов (t.me/addlist/...)")
 	flag.UintVar(&offset, "offset", 0, "смещение выборки каналов")
 	flag.UintVar(&limit, "limit", 100, "ограничение выборки каналов")
 	flag.BoolVar(&isExact, "exact", true, "точное число подписчиков")
 	flag.UintVar(&messages, "messages", 0, "количество сообщений канала")
+	flag.UintVar(&refresh, "refresh", parse.SyncRefreshCount, "число последних известных сообщений с обновлением просмотров при синхронизации")
+	flag.DurationVar(&snapshotEvery, "snapshot-every", 0, "интервал снимков подписчиков и просмотров каналов в режиме сервера (0 - без снимков, нужен -store)")
 	flag.BoolVar(&isTest, "test", false, "тестовый режим (с подборкой каналов)")
+	flag.StringVar(&exportPath, "export", "", "папка или файл экспорта Telegram Desktop")
+	flag.StringVar(&output, "output", "", "файл для списка каналов из экспорта")
+	flag.StringVar(&profile, "profile", "desktop", "профиль заголовков запросов (desktop, mobile, none)")
+	flag.BoolVar(&isInsecure, "insecure", false, "отключение проверки сертификатов")
+	flag.Int64Var(&maxBody, "max-body", get.MaxBodySize, "предельный размер ответа после распаковки в байтах, для http и curl (0 - без ограничения)")
+	flag.IntVar(&cacheEntries, "cache-entries", parse.CacheMaxEntries, "предельное число каналов в кэше (0 - без ограничения)")
+	flag.Int64Var(&cacheSize, "cache-size", parse.CacheMaxBytes, "предельный объем кэша каналов в байтах (0 - без ограничения)")
+	flag.DurationVar(&cacheTTL, "cache-ttl", parse.CacheTTL, "время хранения каналов в кэше")
+	flag.DurationVar(&cacheStaleTTL, "cache-stale-ttl", parse.CacheStaleTTL, "время, в течение которого отдаются устаревшие каналы с обновлением в фоне (0 - не отдаются)")
+	flag.StringVar(&cacheFile, "cache-file", "data/cache.json", "файл снимка кэша каналов (пусто - без сохранения)")
+	flag.DurationVar(&cacheSave, "cache-save", 5*time.Minute, "интервал сохранения снимка кэша каналов")
+	flag.StringVar(&cacheRedis, "cache-redis", "", "адрес Redis для общего кэша каналов (host:port или redis://[:password@]host:port[/db])")
+	flag.StringVar(&adminToken, "admin-token", os.Getenv("PARSER_ADMIN_TOKEN"), "токен страниц администрирования /admin/... и синхронизации /sync (пусто - страницы отключены, можно задать через PARSER_ADMIN_TOKEN)")
+	flag.StringVar(&storeDir, "store", "", "папка хранилища спарсенных каналов, сообщений и снимков (пусто - без сохранения)")
+	flag.StringVar(&cassette, "cassette", "", "файл кассеты с записанными запросами")
+	flag.StringVar(&cassetteMode, "cassette-mode", "replay", "режим кассеты (replay, record, passthrough)")
+	flag.UintVar(&benchRequests, "bench-requests", 20, "число запросов через каждый прокси при замере")
+	flag.BoolVar(&isJSON, "json", false, "вывод замера прокси и синхронизации в JSON")
+	flag.BoolVar(&isRewrite, "rewrite", false, "перезапись файла прокси годными по итогам замера")
 	flag.Parse()
 
 	if isConsole {
@@ -51,13 +96,92 @@ func main() {
 	if isServer {
 		mode = "server"
 	}
+	if exportPath != "" {
+		mode = "import"
+	}
+
+	// Список каналов (через запятую)
+	var peerList []string
+	if peers != "" {
+		list, err := check.List(peers)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		peerList = list
+	}
+
+	// Запросы (заголовки, проверка сертификатов, размер ответа)
+	if err := get.SetProfile(profile); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	get.IsTLSVerify = !isInsecure
+	get.MaxBodySize = maxBody
+
+	// Прокси (из файла, переменной окружения PARSER_PROXIES и url-адреса)
+	var sources []proxy.Source
 
-	// Прокси
 	if isProxy {
+		sources = append(sources, proxy.NewFileSource(proxies), proxy.NewEnvSource("PARSER_PROXIES"))
+		if proxiesURL != "" {
+			sources = append(sources, proxy.NewURLSource(proxiesURL))
+		}
+
 		proxy.Enable(300, 50, 65*time.Second)
-		proxy.PrepareFromFile("data/proxies")
+
+		if _, err := proxy.Load(sources...); err != nil {
+			fmt.Println(err)
+		}
+
+		if err := proxy.SetStrategy(strategy); err != nil {
+			fmt.Println(err)
+			return
+		}
 	}
 
+	// Лимиты запросов на один исходящий адрес (по классам прокси)
+	classRates, err := proxy.ParseRates(rates)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	policy, err := proxy.ParsePolicy(ratePolicy)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for class, rate := range classRates {
+		proxy.SetRate(class, rate.PerMinute, rate.Burst)
+	}
+	proxy.SetPolicy(policy, rateWait)
+
+	// Хранилище спарсенных каналов
+	if storeDir != "" {
+		s, err := storage.OpenFile(storeDir, 0)
+		if err != nil {
+			fmt.Println("Хранилище не открыто:", err)
+			return
+		}
+		defer s.Close()
+
+		if n := s.Skipped(); n > 0 {
+			fmt.Println("Пропущено поврежденных записей хранилища:", n)
+		}
+
+		parse.SetStore(s)
+	}
+
+	// Сохранение записанной кассеты при завершении
+	defer func() {
+		if err := get.FlushCassette(); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
 	// Режим работы
 	switch mode {
 
@@ -67,19 +191,84 @@ func main() {
 		http.HandleFunc("/", page.Index)
 		http.HandleFunc("/info", page.Info)
 		http.HandleFunc("/messages", page.Messages)
+		http.HandleFunc("/proxies", page.Proxies)
+		http.HandleFunc("/sync", page.Sync)
+		http.HandleFunc("/stats/growth", page.Growth)
+		http.HandleFunc("/stats/series", page.Series)
+		http.HandleFunc("/stats/accruals", page.Accruals)
 		http.HandleFunc("/styles.css", page.Styles)
 
+		// Администрирование кэша (с токеном)
+		page.SetAdminToken(adminToken)
+		http.HandleFunc("/admin/cache", page.CacheStats)
+		http.HandleFunc("/admin/cache/keys", page.CacheKeys)
+		http.HandleFunc("/admin/cache/entry", page.CacheEntry)
+		http.HandleFunc("/admin/cache/purge", page.CachePurge)
+
 		// Подготовка шаблонов
 		page.SetTemplate("index", "templates/page.html", "templates/index.html")
 		page.SetTemplate("info", "templates/page.html", "templates/info.html", "templates/form.html")
 		page.SetTemplate("messages", "templates/page.html", "templates/messages.html", "templates/form.html")
 
 		// Выбор транспорта
-		get.SetTransport("http", 0)
+		if err := selectTransport(cassette, cassetteMode); err != nil {
+			fmt.Println(err)
+			return
+		}
 
 		// Включение кэша
-		cache.Enable()
-		cache.CheckEvery(time.Minute)
+		parse.Cache.SetLimits(cacheEntries, cacheSize)
+		parse.Cache.SetTTL(cacheTTL, cacheStaleTTL)
+		parse.Cache.Enable()
+
+		// Общий кэш для нескольких экземпляров
+		if cacheRedis != "" {
+			redis, err := cache.NewRedis(cacheRedis)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			defer redis.Close()
+
+			parse.Cache.SetBackend(redis)
+		}
+		defer parse.Cache.CheckEvery(time.Minute)()
+
+		// Загрузка снимка кэша (устаревшие каналы пропускаются) и периодическое сохранение
+		if cacheFile != "" {
+			if n, err := parse.Cache.LoadFile(cacheFile); err != nil {
+				fmt.Println("Снимок кэша не загружен:", err)
+			} else if n > 0 {
+				fmt.Println("Загружено каналов из снимка кэша:", n)
+			}
+
+			if cacheSave > 0 {
+				defer parse.Cache.SaveEvery(cacheFile, cacheSave, func(err error) { fmt.Println(err) })()
+			}
+		}
+
+		// Периодические снимки подписчиков и просмотров (каналы из параметров или тестовый набор)
+		if snapshotEvery > 0 && parse.Store() != nil {
+			channels := parse.NewChannels()
+
+			isDefault := peer == "" && peers == "" && folder == ""
+			if err := channels.Fill(peer, folder, peerList, isTest || isDefault); err != nil {
+				fmt.Println(err)
+			}
+			channels.Limit(offset, offset+limit)
+
+			defer channels.SyncEvery(snapshotEvery, refresh, func(err error) { fmt.Println(err) })()
+		}
+
+		// Фоновая проверка прокси
+		if isProxy {
+			stop := proxy.StartProbing(time.Minute, get.Probe("https://t.me/telegram"))
+			defer stop()
+
+			// Перезагрузка прокси после изменения источников (без остановки запросов)
+			stopWatch := proxy.Watch(30*time.Second, func(err error) { fmt.Println(err) }, sources...)
+			defer stopWatch()
+		}
 
 		// "Разогрев" http
 		go get.Page("https://telegram.org")
@@ -91,8 +280,31 @@ func main() {
 		fmt.Println("Сервер ожидает подключений...")
 		fmt.Println("(на " + serverLink + ")")
 
-		// Запуск сервера
-		http.ListenAndServe(serverAddr, nil)
+		// Запуск сервера (до сигнала завершения)
+		srv := &http.Server{Addr: serverAddr}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		go func() {
+			<-ctx.Done()
+
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			srv.Shutdown(shutdownCtx)
+		}()
+
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Println(err)
+		}
+
+		// Сохранение снимка кэша при завершении
+		if cacheFile != "" {
+			if err := parse.Cache.SaveFile(cacheFile); err != nil {
+				fmt.Println(err)
+			}
+		}
 
 	// Консоль
 	case "console":
@@ -103,18 +315,63 @@ func main() {
 		channels := parse.NewChannels()
 
 		// Заполнение
-		switch {
-		case peer != "":
-			channels.Add(peer)
-		case len(peers) > 0:
-			channels.PrepareFromString(peers)
-		case isTest:
-			channels.PrepareFromFile("data/channels")
+		if err := channels.Fill(peer, folder, peerList, isTest); err != nil {
+			fmt.Println(err)
+		}
+		channels.Limit(offset, offset+limit)
+
+		// Выбор транспорта
+		if err := selectTransport(cassette, cassetteMode); err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		// Парсинг
+		_, errs := channels.Parse(context.Background(), isExact, messages)
+
+		// Печать
+		channels.Print(true)
+
+		// Отчет
+		channels.PrintReport(errs, false)
+
+		// Время выполнения
+		fmt.Println(time.Since(start))
+
+	// Импорт из экспорта Telegram Desktop
+	case "import":
+		// Инициализация
+		start := time.Now()
+		channels := parse.NewChannels()
+
+		// Сканирование экспорта
+		e, err := export.Scan(exportPath)
+		if err != nil {
+			fmt.Println("Ошибка:", err)
+			return
+		}
+		e.Print()
+
+		// Запись списка в файл
+		if output != "" {
+			n, err := e.Save(output)
+			if err != nil {
+				fmt.Println("Ошибка:", err)
+				return
+			}
+			fmt.Printf("Записано каналов: %d (в %s)\n", n, output)
+			return
 		}
+
+		// Заполнение
+		e.AddTo(&channels.Channels)
 		channels.Limit(offset, offset+limit)
 
 		// Выбор транспорта
-		get.SetTransport("curl", channels.Count())
+		if err := selectTransport(cassette, cassetteMode); err != nil {
+			fmt.Println(err)
+			return
+		}
 
 		// Парсинг
 		_, errs := channels.Parse(context.Background(), isExact, messages)
@@ -128,6 +385,42 @@ func main() {
 		// Время выполнения
 		fmt.Println(time.Since(start))
 
+	// Синхронизация новых сообщений (с хранилищем)
+	case "sync":
+		// Инициализация
+		start := time.Now()
+		channels := parse.NewChannels()
+
+		// Заполнение
+		if err := channels.Fill(peer, folder, peerList, isTest); err != nil {
+			fmt.Println(err)
+		}
+		channels.Limit(offset, offset+limit)
+
+		// Выбор транспорта
+		if err := selectTransport(cassette, cassetteMode); err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		// Синхронизация
+		deltas, errs := channels.Sync(context.Background(), refresh)
+
+		// Печать
+		if isJSON {
+			parse.PrintDeltasJSON(deltas)
+		} else {
+			parse.PrintDeltas(deltas)
+		}
+
+		// Отчет
+		for _, err := range errs {
+			fmt.Println(err)
+		}
+
+		// Время выполнения
+		fmt.Println(time.Since(start))
+
 	// Прокси
 	case "proxy":
 		// Инициализация
@@ -136,10 +429,49 @@ func main() {
 		// Заполнение
 		channels.PrepareFromFile("data/channels")
 
-		// Выбор транспорта
-		get.SetTransport("http", 0)
+		// Нагрузка - страницы каналов по очереди
+		addresses := make([]string, 0, len(channels.Channels.Channels))
+		for _, c := range channels.Channels.Channels {
+			if info, _ := format.PageLink(c.Username, c.Joinchat); info != "" {
+				addresses = append(addresses, info)
+			}
+		}
+
+		// Замер (прямое соединение - для сравнения)
+		options := proxy.BenchmarkOptions{Requests: int(benchRequests), Concurrency: 2, Pause: 200 * time.Millisecond}
+		results := proxy.Benchmark(append([]string{""}, proxy.List()...), get.Probe(addresses...), options, proxy.DefaultThresholds)
+
+		// Печать
+		if isJSON {
+			proxy.PrintResultsJSON(results)
+		} else {
+			proxy.PrintResults(results)
+		}
+
+		// Перезапись файла прокси
+		if isRewrite {
+			n, err := proxy.SaveResults(proxies, results)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
 
-		// Тест прокси
-		channels.TestProxy(50, 1000, 5*time.Second)
+			fmt.Printf("Файл %s перезаписан (%d прокси)\n", proxies, n)
+		}
+	}
+}
+
+// Выбор транспорта (http или кассета с записанными запросами)
+func selectTransport(cassette, cassetteMode string) error {
+	if cassette == "" {
+		get.SetTransport("http")
+		return nil
 	}
+
+	mode, err := get.ParseCassetteMode(cassetteMode)
+	if err != nil {
+		return err
+	}
+
+	return get.UseCassette(cassette, mode)
 }
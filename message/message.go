@@ -107,3 +107,17 @@ func (m *Message) Print() {
 		fmt.Println(m.MessageHtml)
 	}
 }
+
+// Примерный объем сообщения в памяти (в байтах)
+func (m *Message) Size() int64 {
+	size := int64(len(m.MessageHtml)+len(m.MessageText)+len(m.FwdLink)+len(m.FwdTitle)+len(m.FwdAuthor)) + 256
+
+	for _, s := range m.Attachments {
+		size += int64(len(s)) + 16
+	}
+	for _, s := range m.Hashtags {
+		size += int64(len(s)) + 16
+	}
+
+	return size + m.Buttons.Size() + m.Links.Size() + m.Advs.Size()
+}
@@ -0,0 +1,214 @@
+package page
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"statosphere/parser/cache"
+	"statosphere/parser/request"
+	"statosphere/parser/response"
+)
+
+// Администрирование
+type admin struct {
+	token string
+	m     sync.RWMutex
+}
+
+// Объект администрирования
+var a admin
+
+// Статистика кэша
+type cacheStats struct {
+	Namespace string `json:"namespace"`
+	IsEnabled bool   `json:"isEnabled"`
+	Count     int    `json:"count"`
+	Hits      int    `json:"hits"`
+	Misses    int    `json:"misses"`
+	Evicted   int    `json:"evicted"`
+	Bytes     int64  `json:"bytes"`
+}
+
+// Значение кэша с описанием
+type cacheEntry struct {
+	cache.Entry
+	Value any `json:"value"`
+}
+
+// Результат удаления значений кэша
+type cachePurge struct {
+	Namespace string `json:"namespace"`
+	Purged    int    `json:"purged"`
+}
+
+// Запись токена администрирования (пустой токен отключает страницы администрирования)
+func SetAdminToken(token string) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	a.token = token
+}
+
+// Проверка доступа к странице администрирования (токен в заголовке Authorization: Bearer)
+func isAdmin(res http.ResponseWriter, req *http.Request) bool {
+	a.m.RLock()
+	token := a.token
+	a.m.RUnlock()
+
+	if token == "" {
+		response.PrintStatus(res, http.StatusNotFound)
+		return false
+	}
+
+	bearer, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if ok && subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) == 1 {
+		return true
+	}
+
+	// Ограничение числа неудачных попыток (подбора токена)
+	if !request.Barrier("admin", 10, time.Duration(time.Second)) {
+		response.PrintStatus(res, http.StatusTooManyRequests)
+		return false
+	}
+
+	res.Header().Set("WWW-Authenticate", "Bearer")
+	response.PrintStatus(res, http.StatusUnauthorized)
+
+	return false
+}
+
+// Кэш из параметра namespace
+func namespaceOf(res http.ResponseWriter, req *http.Request) (cache.Namespace, bool) {
+	c, ok := cache.Lookup(request.ParamString(req, "namespace", ""))
+	if !ok {
+		response.PrintStatus(res, http.StatusNotFound)
+	}
+
+	return c, ok
+}
+
+// Страница со статистикой кэшей
+func CacheStats(res http.ResponseWriter, req *http.Request) {
+	if !isAdmin(res, req) {
+		return
+	}
+
+	start := time.Now()
+	stats := []cacheStats{}
+
+	for _, c := range cache.Namespaces() {
+		count, hits, misses, evicted := c.Stats()
+
+		stats = append(stats, cacheStats{
+			Namespace: c.Namespace(),
+			IsEnabled: c.IsEnabled(),
+			Count:     count,
+			Hits:      hits,
+			Misses:    misses,
+			Evicted:   evicted,
+			Bytes:     c.Bytes(),
+		})
+	}
+
+	// Подготовка JSON
+	json := response.New(stats, len(stats), nil, time.Since(start))
+	result, _ := json.EncodeJSON()
+
+	// Печать JSON
+	response.PrintJSON(res, result)
+}
+
+// Страница со списком значений кэша (с возрастом и временем хранения)
+func CacheKeys(res http.ResponseWriter, req *http.Request) {
+	if !isAdmin(res, req) {
+		return
+	}
+
+	c, ok := namespaceOf(res, req)
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+	entries := c.Entries(request.ParamString(req, "prefix", ""))
+
+	// Подготовка JSON
+	json := response.New(entries, len(entries), nil, time.Since(start))
+	result, _ := json.EncodeJSON()
+
+	// Печать JSON
+	response.PrintJSON(res, result)
+}
+
+// Страница со значением кэша
+func CacheEntry(res http.ResponseWriter, req *http.Request) {
+	if !isAdmin(res, req) {
+		return
+	}
+
+	c, ok := namespaceOf(res, req)
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+	value, entry, ok := c.Peek(request.ParamString(req, "key", ""))
+	if !ok {
+		response.PrintStatus(res, http.StatusNotFound)
+		return
+	}
+
+	// Подготовка JSON
+	json := response.New(cacheEntry{entry, value}, 1, nil, time.Since(start))
+	result, _ := json.EncodeJSON()
+
+	// Печать JSON
+	response.PrintJSON(res, result)
+}
+
+// Удаление значения кэша, значений по префиксу или всех значений (только POST)
+func CachePurge(res http.ResponseWriter, req *http.Request) {
+	if !isAdmin(res, req) {
+		return
+	}
+
+	if req.Method != http.MethodPost {
+		res.Header().Set("Allow", http.MethodPost)
+		response.PrintStatus(res, http.StatusMethodNotAllowed)
+		return
+	}
+
+	c, ok := namespaceOf(res, req)
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+	purged := cachePurge{Namespace: c.Namespace()}
+
+	key := request.ParamString(req, "key", "")
+	prefix := request.ParamString(req, "prefix", "")
+	isAll := request.ParamBool(req, "all", false)
+
+	switch {
+	case key != "":
+		if c.Purge(key) {
+			purged.Purged = 1
+		}
+	case prefix != "" || isAll:
+		purged.Purged = c.PurgePrefix(prefix)
+	default:
+		response.PrintStatus(res, http.StatusBadRequest)
+		return
+	}
+
+	// Подготовка JSON (ответ успешен, даже если удалять было нечего)
+	json := response.New(purged, 1, nil, time.Since(start))
+	result, _ := json.EncodeJSON()
+
+	// Печать JSON
+	response.PrintJSON(res, result)
+}
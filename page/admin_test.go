@@ -0,0 +1,96 @@
+package page
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"statosphere/parser/cache"
+	"statosphere/parser/mock"
+)
+
+func TestAdmin(t *testing.T) {
+	c := cache.New[string, string](cache.WithNamespace("admin_pages"), cache.WithTTL(time.Hour), cache.WithKeyFunc(strings.ToLower))
+
+	c.Set("https://t.me/first", "first")
+	c.Set("https://t.me/second", "second")
+	c.Set("other", "other")
+	c.Get("other")
+	c.Get("missing")
+
+	defer SetAdminToken("")
+
+	tests := []struct {
+		test    string
+		token   string
+		handler http.HandlerFunc
+		method  string
+		params  map[string]string
+		result  []string
+		exclude []string
+	}{
+		{"Disabled", "", CacheStats, "GET", nil, []string{"404 Not Found"}, nil},
+		{"NoToken", "-", CacheStats, "GET", nil, []string{"401 Unauthorized"}, nil},
+		{"WrongToken", "wrong", CacheStats, "GET", nil, []string{"401 Unauthorized"}, nil},
+		{"Stats", "secret", CacheStats, "GET", nil,
+			[]string{`"ok":true`, `"namespace":"admin_pages"`, `"count":3`, `"hits":1`, `"misses":1`, `"evicted":0`}, nil},
+		{"Keys", "secret", CacheKeys, "GET", map[string]string{"namespace": "admin_pages"},
+			[]string{`"key":"other"`, `"key":"https://t.me/first"`, `"ttl":3599`, `"isStale":false`}, nil},
+		{"KeysPrefix", "secret", CacheKeys, "GET", map[string]string{"namespace": "admin_pages", "prefix": "HTTPS://T.ME/"},
+			[]string{`"key":"https://t.me/first"`, `"key":"https://t.me/second"`}, []string{`"key":"other"`}},
+		{"KeysNamespace", "secret", CacheKeys, "GET", map[string]string{"namespace": "none"}, []string{"404 Not Found"}, nil},
+		{"Entry", "secret", CacheEntry, "GET", map[string]string{"namespace": "admin_pages", "key": "https://t.me/First"},
+			[]string{`"key":"https://t.me/first"`, `"value":"first"`, `"age":0`}, nil},
+		{"EntryMissing", "secret", CacheEntry, "GET", map[string]string{"namespace": "admin_pages", "key": "missing"}, []string{"404 Not Found"}, nil},
+		{"PurgeGet", "secret", CachePurge, "GET", map[string]string{"namespace": "admin_pages", "key": "other"}, []string{"405 Method Not Allowed"}, nil},
+		{"PurgeNothing", "secret", CachePurge, "POST", map[string]string{"namespace": "admin_pages"}, []string{"400 Bad Request"}, nil},
+		{"PurgeKey", "secret", CachePurge, "POST", map[string]string{"namespace": "admin_pages", "key": "https://t.me/first"}, []string{`"purged":1`}, nil},
+		{"PurgePrefix", "secret", CachePurge, "POST", map[string]string{"namespace": "admin_pages", "prefix": "https://t.me/"}, []string{`"purged":1`}, nil},
+		{"PurgeAll", "secret", CachePurge, "POST", map[string]string{"namespace": "admin_pages", "all": "true"}, []string{`"purged":1`}, nil},
+		{"StatsAfter", "secret", CacheStats, "GET", nil, []string{`"count":0`, `"hits":1`}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			SetAdminToken("")
+			if tt.token != "" {
+				SetAdminToken("secret")
+			}
+
+			response := mock.NewResponseWriter()
+			request := mock.NewRequest()
+			request.Header = make(http.Header)
+
+			if tt.token != "" && tt.token != "-" {
+				request.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			for key, value := range tt.params {
+				switch tt.method {
+				case "POST":
+					request.AddParamPOST(key, value)
+				default:
+					request.AddParamGET(key, value)
+				}
+			}
+			request.Method = tt.method
+
+			tt.handler(response, &request.Request)
+
+			result := response.Body()
+
+			for _, match := range tt.result {
+				if !strings.Contains(result, match) {
+					t.Fatalf("Получено значение: %v, ожидаются совпадения: %v, не найдено: %v",
+						result, tt.result, match)
+				}
+			}
+			for _, match := range tt.exclude {
+				if strings.Contains(result, match) {
+					t.Errorf("Получено значение: %v, найдено лишнее: %v", result, match)
+				}
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"statosphere/parser/parse"
+	"statosphere/parser/proxy"
 	"statosphere/parser/request"
 	"statosphere/parser/response"
 )
@@ -100,8 +101,8 @@ func Info(res http.ResponseWriter, req *http.Request) {
 		p := templateForm{
 			Page: templatePage{
 				Title: "Информация",
-				Caption: `Введите ссылки на ТГ-каналы через запятую
-					или выберите режим "Тестовый набор"`,
+				Caption: `Введите ссылки на ТГ-каналы или папки (t.me/addlist/...)
+					через запятую или выберите режим "Тестовый набор"`,
 			},
 			Fields: map[string]templateField{
 				"Channels": {Caption: "Каналы"},
@@ -123,23 +124,20 @@ func Info(res http.ResponseWriter, req *http.Request) {
 	// Параметры
 	peer := request.ParamString(req, "channel", "")
 	peers := request.ParamList(req, "channels", []string{})
+	folder := request.ParamString(req, "folder", "")
 	limit := request.ParamPositiveInt(req, "limit", 100)
 	offset := request.ParamPositiveInt(req, "offset", 0)
 	isTest := request.ParamBool(req, "test", false)
 
 	// Заполнение
-	switch {
-	case peer != "":
-		channels.Add(peer)
-	case len(peers) > 0:
-		channels.PrepareFromList(peers)
-	case isTest:
-		channels.PrepareFromFile("data/channels")
-	}
+	err := channels.Fill(peer, folder, peers, isTest)
 	channels.Limit(offset, offset+limit)
 
 	// Парсинг
 	_, errs := channels.Parse(req.Context(), true, 0)
+	if err != nil {
+		errs = append([]error{err}, errs...)
+	}
 	channels.RemoveUnparsed()
 
 	// Подготовка JSON
@@ -167,8 +165,8 @@ func Messages(res http.ResponseWriter, req *http.Request) {
 		p := templateForm{
 			Page: templatePage{
 				Title: "Сообщения",
-				Caption: `Введите ссылки на ТГ-каналы через запятую или 
-					выберите режим "Тестовый набор"`,
+				Caption: `Введите ссылки на ТГ-каналы или папки (t.me/addlist/...)
+					через запятую или выберите режим "Тестовый набор"`,
 			},
 			Fields: map[string]templateField{
 				"Channels": {Caption: "Каналы"},
@@ -192,6 +190,7 @@ func Messages(res http.ResponseWriter, req *http.Request) {
 	// Параметры
 	peer := request.ParamString(req, "channel", "")
 	peers := request.ParamList(req, "channels", []string{})
+	folder := request.ParamString(req, "folder", "")
 	limit := request.ParamPositiveInt(req, "limit", 100)
 	offset := request.ParamPositiveInt(req, "offset", 0)
 	messages := request.ParamPositiveInt(req, "messages", 20)
@@ -199,18 +198,14 @@ func Messages(res http.ResponseWriter, req *http.Request) {
 	isTest := request.ParamBool(req, "test", false)
 
 	// Заполнение
-	switch {
-	case peer != "":
-		channels.Add(peer)
-	case len(peers) > 0:
-		channels.PrepareFromList(peers)
-	case isTest:
-		channels.PrepareFromFile("data/channels")
-	}
+	err := channels.Fill(peer, folder, peers, isTest)
 	channels.Limit(offset, offset+limit)
 
 	// Парсинг
 	_, errs := channels.Parse(req.Context(), isExact, messages)
+	if err != nil {
+		errs = append([]error{err}, errs...)
+	}
 	channels.RemoveUnmessaged()
 
 	// Подготовка JSON
@@ -224,6 +219,71 @@ func Messages(res http.ResponseWriter, req *http.Request) {
 	response.PrintJSON(res, result)
 }
 
+// Страница с состоянием пула прокси
+func Proxies(res http.ResponseWriter, req *http.Request) {
+
+	// Ограничение числа запросов
+	if !request.Barrier("proxies", 10, time.Duration(time.Second)) {
+		response.PrintStatus(res, http.StatusTooManyRequests)
+		return
+	}
+
+	start := time.Now()
+	states := proxy.States()
+
+	// Подготовка JSON
+	json := response.New(states, len(states), nil, time.Since(start))
+	result, _ := json.EncodeJSON()
+
+	// Печать JSON
+	response.PrintJSON(res, result)
+}
+
+// Синхронизация каналов: новые, измененные и удаленные сообщения (число обновляемых ограничено в parse.Sync)
+func Sync(res http.ResponseWriter, req *http.Request) {
+
+	// Синхронизация пишет в хранилище - только с токеном администрирования
+	if !isAdmin(res, req) {
+		return
+	}
+
+	// Ограничение числа запросов
+	if !request.Barrier("sync", 5, time.Duration(time.Second)) {
+		response.PrintStatus(res, http.StatusTooManyRequests)
+		return
+	}
+
+	// Инициализация
+	start := time.Now()
+	channels := parse.NewChannels()
+
+	// Параметры
+	peer := request.ParamString(req, "channel", "")
+	peers := request.ParamList(req, "channels", []string{})
+	folder := request.ParamString(req, "folder", "")
+	limit := request.ParamPositiveInt(req, "limit", 100)
+	offset := request.ParamPositiveInt(req, "offset", 0)
+	refresh := request.ParamPositiveInt(req, "refresh", parse.SyncRefreshCount)
+	isTest := request.ParamBool(req, "test", false)
+
+	// Заполнение
+	err := channels.Fill(peer, folder, peers, isTest)
+	channels.Limit(offset, offset+limit)
+
+	// Синхронизация
+	deltas, errs := channels.Sync(req.Context(), refresh)
+	if err != nil {
+		errs = append([]error{err}, errs...)
+	}
+
+	// Подготовка JSON
+	json := response.New(deltas, len(deltas), errs, time.Since(start))
+	result, _ := json.EncodeJSON()
+
+	// Печать JSON
+	response.PrintJSON(res, result)
+}
+
 // Страница со стилями
 func Styles(res http.ResponseWriter, req *http.Request) {
 	http.ServeFile(res, req, "templates/styles.css")
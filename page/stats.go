@@ -0,0 +1,177 @@
+package page
+
+import (
+	"net/http"
+	"time"
+
+	"statosphere/parser/parse"
+	"statosphere/parser/request"
+	"statosphere/parser/response"
+	"statosphere/parser/series"
+	"statosphere/parser/storage"
+)
+
+// Параметры страниц статистики (хранилище, каналы и период)
+type statsParams struct {
+	store     storage.Store
+	peers     []string
+	messageID uint
+	from      time.Time
+	to        time.Time
+}
+
+// Разбор параметров страниц статистики (без каналов - все каналы хранилища)
+func statsParamsOf(req *http.Request) (statsParams, []error) {
+	p := statsParams{
+		store:     parse.Store(),
+		messageID: request.ParamPositiveInt(req, "message", 0),
+		from:      request.ParamTime(req, "from", time.Time{}),
+		to:        request.ParamTime(req, "to", time.Time{}),
+	}
+
+	if p.store == nil {
+		return p, []error{parse.ErrNoStore}
+	}
+
+	channels := parse.NewChannels()
+
+	peer := request.ParamString(req, "channel", "")
+	peers := request.ParamList(req, "channels", []string{})
+
+	if peer == "" && len(peers) == 0 {
+		p.peers = p.store.Peers()
+		return p, nil
+	}
+
+	if err := channels.Fill(peer, "", peers, false); err != nil {
+		return p, []error{err}
+	}
+
+	for _, c := range channels.Channels.Channels {
+		p.peers = append(p.peers, c.Peer)
+	}
+
+	return p, nil
+}
+
+// Страница с ростом подписчиков каналов (или просмотров сообщения) за период
+func Growth(res http.ResponseWriter, req *http.Request) {
+
+	// Ограничение числа запросов
+	if !request.Barrier("growth", 10, time.Duration(time.Second)) {
+		response.PrintStatus(res, http.StatusTooManyRequests)
+		return
+	}
+
+	start := time.Now()
+	growth := []series.Growth{}
+
+	p, errs := statsParamsOf(req)
+
+	if len(errs) == 0 {
+		for _, peer := range p.peers {
+			sr, err := series.Load(p.store, peer, p.messageID, p.from, p.to)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			if g, ok := sr.Growth(); ok {
+				growth = append(growth, g)
+			}
+		}
+	}
+
+	// Подготовка JSON
+	json := response.New(growth, len(growth), errs, time.Since(start))
+	result, _ := json.EncodeJSON()
+
+	// Печать JSON
+	response.PrintJSON(res, result)
+}
+
+// Страница с временными рядами подписчиков каналов (или просмотров сообщения) в JSON или CSV
+func Series(res http.ResponseWriter, req *http.Request) {
+
+	// Ограничение числа запросов
+	if !request.Barrier("series", 10, time.Duration(time.Second)) {
+		response.PrintStatus(res, http.StatusTooManyRequests)
+		return
+	}
+
+	start := time.Now()
+	list := []series.Series{}
+
+	p, errs := statsParamsOf(req)
+
+	if len(errs) == 0 {
+		for _, peer := range p.peers {
+			sr, err := series.Load(p.store, peer, p.messageID, p.from, p.to)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			if len(sr.Points) > 0 {
+				list = append(list, sr)
+			}
+		}
+	}
+
+	// CSV - только для одного ряда
+	if request.ParamString(req, "format", "json") == "csv" {
+		if len(list) != 1 {
+			response.PrintStatus(res, http.StatusNotFound)
+			return
+		}
+
+		res.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		list[0].WriteCSV(res)
+		return
+	}
+
+	// Подготовка JSON
+	json := response.New(list, len(list), errs, time.Since(start))
+	result, _ := json.EncodeJSON()
+
+	// Печать JSON
+	response.PrintJSON(res, result)
+}
+
+// Страница с кривыми набора просмотров сообщений, опубликованных за период
+func Accruals(res http.ResponseWriter, req *http.Request) {
+
+	// Ограничение числа запросов
+	if !request.Barrier("accruals", 10, time.Duration(time.Second)) {
+		response.PrintStatus(res, http.StatusTooManyRequests)
+		return
+	}
+
+	start := time.Now()
+	accruals := []series.Accrual{}
+
+	p, errs := statsParamsOf(req)
+
+	if len(errs) == 0 {
+		for _, peer := range p.peers {
+			list, err := series.Accruals(p.store, peer, p.from, p.to)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			for _, a := range list {
+				if p.messageID == 0 || a.MessageID == p.messageID {
+					accruals = append(accruals, a)
+				}
+			}
+		}
+	}
+
+	// Подготовка JSON
+	json := response.New(accruals, len(accruals), errs, time.Since(start))
+	result, _ := json.EncodeJSON()
+
+	// Печать JSON
+	response.PrintJSON(res, result)
+}
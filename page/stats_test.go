@@ -0,0 +1,83 @@
+package page
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"statosphere/parser/channel"
+	"statosphere/parser/message"
+	"statosphere/parser/mock"
+	"statosphere/parser/parse"
+	"statosphere/parser/storage"
+	"statosphere/parser/value"
+)
+
+func TestStats(t *testing.T) {
+	s, err := storage.OpenFile(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	defer s.Close()
+
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	s.UpsertChannel(&channel.Channel{Peer: "@codecamp", Title: "Канал"})
+	s.UpsertMessages("@codecamp", []*message.Message{{ID: 7, Date: base}})
+	s.AppendSnapshots(
+		storage.Snapshot{Peer: "@codecamp", Value: value.Value{Exact: 1000}, Time: base},
+		storage.Snapshot{Peer: "@codecamp", Value: value.Value{Exact: 1100}, Time: base.Add(48 * time.Hour)},
+		storage.Snapshot{Peer: "@codecamp", MessageID: 7, Value: value.Value{Exact: 50}, Time: base.Add(time.Hour)},
+		storage.Snapshot{Peer: "@codecamp", MessageID: 7, Value: value.Value{Exact: 500}, Time: base.Add(48 * time.Hour)},
+	)
+
+	defer parse.SetStore(nil)
+
+	tests := []struct {
+		test    string
+		store   storage.Store
+		handler http.HandlerFunc
+		params  map[string]string
+		result  []string
+	}{
+		{"NoStore", nil, Growth, nil, []string{`"ok":false`, "хранилище не задано"}},
+		{"Growth", s, Growth, map[string]string{"channel": "codecamp", "from": "2024-03-01", "to": "2024-03-05"},
+			[]string{`"ok":true`, `"peer":"@codecamp"`, `"start":1000`, `"end":1100`, `"absolute":100`,
+				`"percent":10`, `"dailyAverage":25`}},
+		{"GrowthAll", s, Growth, map[string]string{"from": "2024-03-02"},
+			[]string{`"ok":true`, `"from":"2024-03-02T00:00:00Z"`, `"start":1000`, `"absolute":100`}},
+		{"GrowthMessage", s, Growth, map[string]string{"channel": "codecamp", "message": "7"},
+			[]string{`"messageId":7`, `"start":50`, `"end":500`}},
+		{"Series", s, Series, map[string]string{"channels": "[codecamp]", "to": "2024-03-02"},
+			[]string{`"ok":true`, `"points":[{"time":"2024-03-01T00:00:00Z","value":1000},{"time":"2024-03-02T00:00:00Z","value":1000}]`}},
+		{"SeriesCSV", s, Series, map[string]string{"channel": "codecamp", "format": "csv"},
+			[]string{"time,value\n2024-03-01T00:00:00Z,1000\n2024-03-03T00:00:00Z,1100\n"}},
+		{"Accruals", s, Accruals, map[string]string{"channel": "codecamp"},
+			[]string{`"ok":true`, `"messageId":7`, `"after":"1h"`, `"value":50`, `"after":"24h"`, `"after":"7d","time":"2024-03-08T00:00:00Z","value":500,"isExtrapolated":true`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			parse.SetStore(tt.store)
+
+			response := mock.NewResponseWriter()
+			request := mock.NewRequest()
+
+			for k, v := range tt.params {
+				request.AddParamGET(k, v)
+			}
+
+			tt.handler(response, &request.Request)
+
+			result := response.Body()
+
+			for _, match := range tt.result {
+				if !strings.Contains(result, match) {
+					t.Fatalf("Получено значение: %v, ожидаются совпадения: %v, не найдено: %v",
+						result, tt.result, match)
+				}
+			}
+		})
+	}
+}
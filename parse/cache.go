@@ -0,0 +1,246 @@
+package parse
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"statosphere/parser/cache"
+	"statosphere/parser/channel"
+	"statosphere/parser/message"
+	"statosphere/parser/response"
+)
+
+// Параметры кэша каналов по умолчанию
+const (
+	CacheMaxEntries       = 1000             // Число каналов
+	CacheMaxBytes   int64 = 256 << 20        // Примерный объем каналов с сообщениями
+	CacheTTL              = 5 * time.Minute  // Время хранения
+	CacheStaleTTL         = 15 * time.Minute // Время, в течение которого отдаются устаревшие данные (с обновлением в фоне)
+)
+
+// Время, после которого сообщения в кэше дополняются новыми (0 - не дополняются)
+var CacheRefreshAge = time.Minute
+
+// Число сообщений на одной странице канала
+const messagesPerPage = 20
+
+// Канал в кэше с описанием содержимого
+type CachedChannel struct {
+	Channel       *channel.Channel `json:"channel"`
+	IsExact       bool             `json:"isExact"`       // Точное число подписчиков
+	MessagesCount uint             `json:"messagesCount"` // Запрошенное число сообщений (если сообщений меньше - получены все)
+	FetchedAt     time.Time        `json:"fetchedAt"`     // Время получения последних сообщений
+}
+
+// Кэш спарсенных каналов (по ссылке, включается в режиме сервера)
+var Cache = cache.New[string, *CachedChannel](
+	cache.WithNamespace("channels"),
+	cache.WithTTL(CacheTTL),
+	cache.WithStaleTTL(CacheStaleTTL),
+	cache.WithKeyFunc(strings.ToLower),
+	cache.WithMaxEntries(CacheMaxEntries),
+	cache.WithMaxBytes(CacheMaxBytes, (*CachedChannel).Size),
+	cache.WithEnabled(false),
+)
+
+// Примерный объем канала в памяти (в байтах)
+func (cc *CachedChannel) Size() int64 {
+	return cc.Channel.Size()
+}
+
+// Получены ли все сообщения канала
+func (cc *CachedChannel) isComplete() bool {
+	return uint(len(cc.Channel.Messages)) < cc.MessagesCount
+}
+
+// Есть ли в кэше заданное число сообщений
+func (cc *CachedChannel) hasMessages(messagesCount uint) bool {
+	return messagesCount <= cc.MessagesCount || cc.isComplete()
+}
+
+// Устарели ли сообщения в кэше
+func (cc *CachedChannel) isStale(messagesCount uint) bool {
+	return messagesCount > 0 && len(cc.Channel.Messages) > 0 &&
+		CacheRefreshAge > 0 && time.Since(cc.FetchedAt) > CacheRefreshAge
+}
+
+// Есть ли в кэше данные для запроса (независимо от их возраста)
+func (cc *CachedChannel) Contains(isExactParticipants bool, messagesCount uint) bool {
+	if isExactParticipants && !cc.IsExact {
+		return false
+	}
+
+	return cc.hasMessages(messagesCount)
+}
+
+// Достаточно ли данных в кэше для запроса
+func (cc *CachedChannel) Covers(isExactParticipants bool, messagesCount uint) bool {
+	return cc.Contains(isExactParticipants, messagesCount) && !cc.isStale(messagesCount)
+}
+
+// Копия канала с заданным числом последних сообщений
+func (cc *CachedChannel) View(messagesCount uint) *channel.Channel {
+	c := *cc.Channel
+
+	switch {
+	case messagesCount == 0:
+		c.Messages = nil
+	case uint(len(c.Messages)) > messagesCount:
+		c.Messages = c.Messages[:messagesCount:messagesCount]
+	}
+
+	return &c
+}
+
+// Копия устаревшего канала (с отметкой и возрастом данных)
+func (cc *CachedChannel) StaleView(messagesCount uint) *channel.Channel {
+	c := cc.View(messagesCount)
+	c.IsStale = true
+	c.Age = uint(time.Since(cc.FetchedAt).Seconds())
+
+	return c
+}
+
+// Обновление устаревшего канала в фоне (одно на канал, с не меньшим набором данных)
+func revalidate(entry CachedChannel, isExactParticipants bool, messagesCount uint) {
+	isExactParticipants = isExactParticipants || entry.IsExact
+	if entry.MessagesCount > messagesCount {
+		messagesCount = entry.MessagesCount
+	}
+
+	c := *entry.Channel
+	c.Messages = nil
+
+	fetches.DoChan("revalidate:"+strings.ToLower(c.Link), func() (fetched, error) {
+		res := fetch(c, isExactParticipants, messagesCount)
+
+		// Канал, замененный в кэше за время обновления, не заменяется
+		cached := merge(&c, res)
+		if cached != nil && Cache.CompareAndSet(c.Link, cached, func(old *CachedChannel, ok bool) bool {
+			return !ok || old.FetchedAt.Equal(entry.FetchedAt)
+		}) {
+			persist(cached.Channel)
+		}
+
+		return res, nil
+	})
+}
+
+// Дополнение канала из кэша (точным числом подписчиков, новыми и старыми сообщениями)
+func topUp(entry CachedChannel, isExactParticipants bool, messagesCount uint) (fetched, bool) {
+	c := *entry.Channel
+	c.Messages = append([]*message.Message(nil), entry.Channel.Messages...)
+
+	res := fetched{
+		isExact:       entry.IsExact,
+		messagesCount: entry.MessagesCount,
+		fetchedAt:     entry.FetchedAt,
+	}
+	if messagesCount > res.messagesCount {
+		res.messagesCount = messagesCount
+	}
+
+	// Точное число подписчиков - со страницы информации
+	if isExactParticipants && !entry.IsExact {
+		chInfo := make(chan response.Response)
+		go Info(chInfo, c)
+
+		resInfo := <-chInfo
+		if !resInfo.Ok {
+			return res, false
+		}
+
+		c = resInfo.Data.(channel.Channel)
+		res.isExact = true
+	}
+
+	// Новые сообщения (если их не меньше страницы - проще получить канал заново)
+	if entry.isStale(messagesCount) {
+		newer, ok := messagesOf(c, messagesPerPage, c.Messages[0].ID, 0)
+		if !ok || len(newer.Messages) >= messagesPerPage {
+			return res, false
+		}
+
+		c.Photos, c.Videos, c.Files, c.Links = newer.Photos, newer.Videos, newer.Files, newer.Links
+		if !res.isExact {
+			c.Participants = newer.Participants
+		}
+
+		c.Messages = mergeMessages(newer.Messages, c.Messages)
+		res.fetchedAt = time.Now()
+	}
+
+	// Старые сообщения (недостающие после добавления новых)
+	if have := uint(len(c.Messages)); !entry.hasMessages(messagesCount) && have < messagesCount {
+		var (
+			older channel.Channel
+			ok    bool
+		)
+
+		if have == 0 {
+			older, ok = messagesOf(c, messagesCount, 0, 0)
+			if ok {
+				c.Photos, c.Videos, c.Files, c.Links = older.Photos, older.Videos, older.Files, older.Links
+			}
+		} else {
+			older, ok = messagesOf(c, messagesCount-have, 0, c.Messages[have-1].ID)
+		}
+
+		if !ok {
+			return res, false
+		}
+
+		c.Messages = mergeMessages(c.Messages, older.Messages)
+	}
+
+	res.msgs = response.Response{Ok: true, Code: 200, Data: c}
+
+	return res, true
+}
+
+// Получение сообщений канала (после или до заданных сообщений)
+func messagesOf(c channel.Channel, messagesCount, afterMsgId, beforeMsgId uint) (channel.Channel, bool) {
+	chMsgs := make(chan response.Response)
+	c.Messages = nil
+
+	if beforeMsgId != 0 {
+		go MessagesBefore(chMsgs, c, messagesCount, beforeMsgId)
+	} else {
+		go Messages(chMsgs, c, messagesCount, afterMsgId, true)
+	}
+
+	res := <-chMsgs
+	if !res.Ok {
+		return channel.Channel{}, false
+	}
+
+	data, ok := res.Data.(channel.Channel)
+
+	return data, ok
+}
+
+// Объединение сообщений (по ID от новых к старым, без повторов - повтор берется из новых)
+func mergeMessages(newer, older []*message.Message) []*message.Message {
+	var (
+		messages = make([]*message.Message, 0, len(newer)+len(older))
+		ids      = make(map[uint]bool, len(newer)+len(older))
+	)
+
+	for _, list := range [][]*message.Message{newer, older} {
+		for _, m := range list {
+			if m == nil || ids[m.ID] {
+				continue
+			}
+
+			ids[m.ID] = true
+			messages = append(messages, m)
+		}
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].ID > messages[j].ID
+	})
+
+	return messages
+}
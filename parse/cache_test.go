@@ -0,0 +1,429 @@
+package parse
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"statosphere/parser/channel"
+	"statosphere/parser/get"
+	"statosphere/parser/message"
+	"statosphere/parser/value"
+)
+
+// Сообщения с заданными ID
+func messagesWithIds(ids ...uint) []*message.Message {
+	messages := make([]*message.Message, 0, len(ids))
+	for _, id := range ids {
+		messages = append(messages, &message.Message{ID: id})
+	}
+	return messages
+}
+
+// ID сообщений
+func idsOf(messages []*message.Message) []uint {
+	ids := make([]uint, 0, len(messages))
+	for _, m := range messages {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+func TestCovers(t *testing.T) {
+	defer func(age time.Duration) { CacheRefreshAge = age }(CacheRefreshAge)
+	CacheRefreshAge = time.Minute
+
+	tests := []struct {
+		test     string
+		entry    CachedChannel
+		isExact  bool
+		messages uint
+		count    int // Сообщений в кэше (меньше запрошенных - получены все)
+		result   bool
+	}{
+		{"Same", CachedChannel{IsExact: true, MessagesCount: 10}, true, 10, 10, true},
+		{"Fewer", CachedChannel{MessagesCount: 10}, false, 5, 10, true},
+		{"More", CachedChannel{MessagesCount: 10}, false, 20, 10, false},
+		{"MoreComplete", CachedChannel{MessagesCount: 10}, false, 50, 8, true},
+		{"NoMessages", CachedChannel{IsExact: true}, true, 0, 10, true},
+		{"Exact", CachedChannel{MessagesCount: 10}, true, 5, 10, false},
+		{"Approx", CachedChannel{IsExact: true, MessagesCount: 10}, false, 5, 10, true},
+		{"Stale", CachedChannel{MessagesCount: 10, FetchedAt: time.Now().Add(-2 * time.Minute)}, false, 5, 10, false},
+		{"StaleNoMessages", CachedChannel{MessagesCount: 10, FetchedAt: time.Now().Add(-2 * time.Minute)}, false, 0, 10, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			if tt.entry.FetchedAt.IsZero() {
+				tt.entry.FetchedAt = time.Now()
+			}
+
+			ids := []uint{10, 9, 8, 7, 6, 5, 4, 3, 2, 1}
+			tt.entry.Channel = &channel.Channel{Messages: messagesWithIds(ids[:tt.count]...)}
+
+			result := tt.entry.Covers(tt.isExact, tt.messages)
+
+			if result != tt.result {
+				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
+			}
+		})
+	}
+}
+
+func TestView(t *testing.T) {
+	entry := CachedChannel{Channel: &channel.Channel{Title: "Канал", Messages: messagesWithIds(5, 4, 3, 2, 1)}}
+
+	tests := []struct {
+		test     string
+		messages uint
+		result   []uint
+	}{
+		{"None", 0, []uint{}},
+		{"Fewer", 2, []uint{5, 4}},
+		{"All", 5, []uint{5, 4, 3, 2, 1}},
+		{"More", 10, []uint{5, 4, 3, 2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			result := entry.View(tt.messages)
+
+			if ids := idsOf(result.Messages); !reflect.DeepEqual(ids, tt.result) {
+				t.Errorf("Получено значение: %v, ожидается: %v", ids, tt.result)
+			}
+			if result.Title != "Канал" || result == entry.Channel {
+				t.Errorf("Получена не копия канала: %+v", result)
+			}
+		})
+	}
+
+	// Добавление сообщений в копию не меняет кэш
+	result := entry.View(2)
+	result.Messages = append(result.Messages, &message.Message{ID: 100})
+
+	if entry.Channel.Messages[2].ID != 3 {
+		t.Errorf("Сообщения в кэше изменены: %v", idsOf(entry.Channel.Messages))
+	}
+}
+
+func TestMergeMessages(t *testing.T) {
+	tests := []struct {
+		test   string
+		newer  []uint
+		older  []uint
+		result []uint
+	}{
+		{"Empty", nil, nil, []uint{}},
+		{"Newer", []uint{12, 11}, []uint{10, 9}, []uint{12, 11, 10, 9}},
+		{"Overlap", []uint{11, 10}, []uint{10, 9}, []uint{11, 10, 9}},
+		{"Older", []uint{10, 9}, []uint{8, 7}, []uint{10, 9, 8, 7}},
+		{"OutOfOrder", []uint{10, 9}, []uint{11, 8}, []uint{11, 10, 9, 8}},
+		{"Unsorted", []uint{9, 12}, []uint{10, 12, 7}, []uint{12, 10, 9, 7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			result := idsOf(mergeMessages(messagesWithIds(tt.newer...), messagesWithIds(tt.older...)))
+
+			if !reflect.DeepEqual(result, tt.result) {
+				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
+			}
+		})
+	}
+}
+
+func TestParseCache(t *testing.T) {
+	defer get.SetTransport("file")
+	defer func(age time.Duration) { CacheRefreshAge = age }(CacheRefreshAge)
+	defer Cache.Disable()
+
+	if err := get.UseCassette("data/cassettes/codecamp.json", get.CassetteReplay); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	Cache.Enable()
+	Cache.Clear()
+	CacheRefreshAge = time.Minute
+
+	const link = "https://t.me/codecamp"
+
+	tests := []struct {
+		test     string
+		isExact  bool
+		messages uint
+		isStale  bool
+		isCached bool // Ответ из кэша без запросов
+		count    int
+		first    uint
+		last     uint
+		cached   uint // Сообщений в кэше
+		isExactC bool
+	}{
+		{"Fetch", false, 20, false, false, 20, 2376, 2357, 20, false},
+		{"Fewer", false, 5, false, true, 5, 2376, 2372, 20, false},
+		{"NoMessages", false, 0, false, true, 0, 0, 0, 20, false},
+		{"Older", false, 35, false, false, 35, 2376, 2342, 35, false},
+		{"Exact", true, 10, false, false, 10, 2376, 2367, 35, true},
+		{"ExactCached", true, 35, false, true, 35, 2376, 2342, 35, true},
+		{"StaleRefetch", true, 10, true, false, 10, 2376, 2367, 10, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			before, _ := Cache.Get(link)
+
+			// Устаревшие сообщения дополняются с ?after=, которого нет на кассете - канал получается заново
+			if tt.isStale {
+				before.FetchedAt = time.Now().Add(-2 * CacheRefreshAge)
+			}
+
+			pc := NewChannels()
+			pc.Add("codecamp")
+
+			if parsed, errs := pc.Parse(context.Background(), tt.isExact, tt.messages); parsed != 1 {
+				t.Fatalf("Получено значение: %v, ожидается: %v (ошибки: %v)", parsed, 1, errs)
+			}
+
+			c := pc.Channels.Channels[0]
+			after, _ := Cache.Get(link)
+
+			if isCached := before != nil && before == after; isCached != tt.isCached {
+				t.Errorf("Из кэша - получено значение: %v, ожидается: %v", isCached, tt.isCached)
+			}
+			if len(c.Messages) != tt.count {
+				t.Fatalf("Получено сообщений: %v, ожидается: %v", len(c.Messages), tt.count)
+			}
+			if tt.count > 0 {
+				if first, last := c.Messages[0].ID, c.Messages[len(c.Messages)-1].ID; first != tt.first || last != tt.last {
+					t.Errorf("Получены ID: %v-%v, ожидаются: %v-%v", first, last, tt.first, tt.last)
+				}
+			}
+			if uint(len(after.Channel.Messages)) != tt.cached {
+				t.Errorf("В кэше сообщений: %v, ожидается: %v", len(after.Channel.Messages), tt.cached)
+			}
+			if after.IsExact != tt.isExactC || tt.isExactC && !c.Participants.IsExact() {
+				t.Errorf("Точное число подписчиков: %v (%v), ожидается: %v", after.IsExact, c.Participants, tt.isExactC)
+			}
+			if c.Title == "" || c.Participants == (value.Value{}) {
+				t.Errorf("Канал не спарсен: %+v", c)
+			}
+
+			// Сообщения без пропусков и повторов
+			for i := 1; i < len(after.Channel.Messages); i++ {
+				if after.Channel.Messages[i].ID != after.Channel.Messages[i-1].ID-1 {
+					t.Fatalf("ID не по порядку: %v после %v", after.Channel.Messages[i].ID, after.Channel.Messages[i-1].ID)
+				}
+			}
+		})
+	}
+}
+
+func TestParseTopUp(t *testing.T) {
+	defer get.SetTransport("file")
+	defer func(age time.Duration) { CacheRefreshAge = age }(CacheRefreshAge)
+	defer Cache.Disable()
+
+	// Кассета с новыми сообщениями после 2366 (без 2370 и без запросов старых сообщений)
+	synced, err := get.LoadCassette("data/cassettes/codecamp_sync.json", get.CassetteReplay)
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	cassette, err := get.LoadCassette(t.TempDir()+"/codecamp.json", get.CassetteRecord)
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	for url, as := range map[string]string{
+		"https://t.me/codecamp":              "https://t.me/codecamp",
+		"https://t.me/s/codecamp":            "https://t.me/s/codecamp",
+		"https://t.me/s/codecamp?after=2366": "https://t.me/s/codecamp",
+	} {
+		i, ok := synced.Find(as)
+		if !ok {
+			t.Fatalf("Нет запроса на кассете: %v", as)
+		}
+
+		i.URL = url
+		cassette.Add(i)
+	}
+
+	if err := cassette.Flush(); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	if err := get.UseCassette("data/cassettes/codecamp.json", get.CassetteReplay); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	Cache.Enable()
+	Cache.Clear()
+	CacheRefreshAge = time.Minute
+
+	const link = "https://t.me/codecamp"
+
+	parse := func(messages uint) *channel.Channel {
+		pc := NewChannels()
+		pc.Add("codecamp")
+
+		if parsed, errs := pc.Parse(context.Background(), false, messages); parsed != 1 {
+			t.Fatalf("Получено значение: %v, ожидается: %v (ошибки: %v)", parsed, 1, errs)
+		}
+
+		return pc.Channels.Channels[0]
+	}
+
+	// В кэше - 10 устаревших сообщений 2366-2357
+	parse(20)
+
+	entry, _ := Cache.Get(link)
+	c := *entry.Channel
+	c.Messages = c.Messages[10:]
+
+	Cache.Set(link, &CachedChannel{Channel: &c, MessagesCount: 10, FetchedAt: time.Now().Add(-2 * CacheRefreshAge)})
+
+	if err := get.UseCassette(cassette.Filename, get.CassetteReplay); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	// Новых сообщений больше, чем запрошено - старые не запрашиваются
+	result := parse(12)
+
+	if ids := idsOf(result.Messages); len(ids) != 12 || ids[0] != 2376 || ids[11] != 2364 {
+		t.Errorf("Получены ID: %v", ids)
+	}
+
+	entry, _ = Cache.Get(link)
+	if len(entry.Channel.Messages) != 19 || entry.MessagesCount != 12 {
+		t.Errorf("В кэше сообщений: %v (%v), ожидается: %v (%v)", len(entry.Channel.Messages), entry.MessagesCount, 19, 12)
+	}
+}
+
+func TestParseStale(t *testing.T) {
+	defer get.SetTransport("file")
+	defer Cache.SetTTL(CacheTTL, CacheStaleTTL)
+	defer Cache.Disable()
+
+	if err := get.UseCassette("data/cassettes/codecamp.json", get.CassetteReplay); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	Cache.Enable()
+	Cache.Clear()
+	Cache.SetTTL(CacheTTL, time.Minute)
+
+	const link = "https://t.me/codecamp"
+
+	parse := func(isExact bool, messages uint) *channel.Channel {
+		pc := NewChannels()
+		pc.Add("codecamp")
+
+		if parsed, errs := pc.Parse(context.Background(), isExact, messages); parsed != 1 {
+			t.Fatalf("Получено значение: %v, ожидается: %v (ошибки: %v)", parsed, 1, errs)
+		}
+
+		return pc.Channels.Channels[0]
+	}
+
+	// Устаревание записи кэша (полученной 30 секунд назад)
+	expire := func() {
+		entry, _, _ := Cache.GetStale(link)
+		entry.FetchedAt = time.Now().Add(-30 * time.Second)
+		Cache.SetWithTTL(link, entry, 0)
+	}
+
+	parse(false, 10)
+	expire()
+
+	tests := []struct {
+		test     string
+		isExact  bool
+		messages uint
+		isStale  bool
+		count    int
+	}{
+		{"Stale", false, 5, true, 5},
+		{"StaleAgain", false, 10, true, 10},
+		{"NotContained", true, 10, false, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			c := parse(tt.isExact, tt.messages)
+
+			if c.IsStale != tt.isStale {
+				t.Errorf("Получено значение: %v, ожидается: %v", c.IsStale, tt.isStale)
+			}
+			if tt.isStale && (c.Age < 30 || c.Age > 60) {
+				t.Errorf("Получен возраст: %v, ожидается: около 30", c.Age)
+			}
+			if len(c.Messages) != tt.count {
+				t.Errorf("Получено сообщений: %v, ожидается: %v", len(c.Messages), tt.count)
+			}
+		})
+	}
+
+	// Обновление в фоне (после завершения предыдущих обновлений - иначе новое к ним присоединится)
+	for deadline := time.Now().Add(time.Second); fetches.InFlight() > 0 && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	expire()
+	if c := parse(false, 5); !c.IsStale {
+		t.Fatalf("Получены не устаревшие данные")
+	}
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if _, isStale, _ := Cache.GetStale(link); !isStale {
+			break
+		}
+	}
+
+	entry, isStale, ok := Cache.GetStale(link)
+	if !ok || isStale || !entry.IsExact || len(entry.Channel.Messages) != 10 {
+		t.Fatalf("Кэш не обновлен: %v %v %+v", ok, isStale, entry)
+	}
+
+	if c := parse(false, 5); c.IsStale || c.Age != 0 {
+		t.Errorf("Получены устаревшие данные: %v (%v)", c.IsStale, c.Age)
+	}
+}
+
+func TestCacheSnapshot(t *testing.T) {
+	defer Cache.Disable()
+
+	Cache.Enable()
+	Cache.Clear()
+
+	entry := &CachedChannel{
+		Channel:       &channel.Channel{Link: "https://t.me/codecamp", Title: "Канал", Messages: messagesWithIds(3, 2, 1)},
+		IsExact:       true,
+		MessagesCount: 3,
+		FetchedAt:     time.Now().Add(-time.Minute).Round(time.Second),
+	}
+	Cache.Set(entry.Channel.Link, entry)
+
+	var buf bytes.Buffer
+	if err := Cache.Snapshot(&buf); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	Cache.Clear()
+
+	if n, err := Cache.Restore(&buf); n != 1 || err != nil {
+		t.Fatalf("Получено значение: %v (%v)", n, err)
+	}
+
+	restored, ok := Cache.Get("https://t.me/CodeCamp")
+	if !ok {
+		t.Fatalf("Канал не загружен из снимка")
+	}
+	if restored.Channel.Title != "Канал" || !restored.IsExact || restored.MessagesCount != 3 || !restored.FetchedAt.Equal(entry.FetchedAt) {
+		t.Errorf("Получено значение: %+v", restored)
+	}
+	if ids := idsOf(restored.Channel.Messages); !reflect.DeepEqual(ids, []uint{3, 2, 1}) {
+		t.Errorf("Получено значение: %v", ids)
+	}
+}
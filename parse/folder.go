@@ -0,0 +1,105 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+
+	"statosphere/parser/channels"
+	"statosphere/parser/check"
+	"statosphere/parser/file"
+	"statosphere/parser/format"
+	"statosphere/parser/get"
+)
+
+// Файл тестового набора каналов
+const TestChannelsFile = "data/channels"
+
+// Получение ссылок на каналы из папки (t.me/addlist/...)
+func Folder(link string) (string, []string, error) {
+	folderLink := format.Addlist(check.Addlist(link))
+
+	if folderLink == "" {
+		return "", nil, fmt.Errorf("Ошибка папки: %w", fmt.Errorf("неверная ссылка %s", link))
+	}
+
+	code, page, err := get.Page(folderLink)
+	if err != nil {
+		return folderLink, nil, fmt.Errorf("Ошибка папки %s: %w", folderLink, err)
+	}
+	if code != 200 {
+		return folderLink, nil, fmt.Errorf("Ошибка папки %s: %w", folderLink, fmt.Errorf("код ответа %d", code))
+	}
+
+	return folderLink, channels.Folder(page), nil
+}
+
+// Добавление каналов из папки
+func (pc *Channels) AddFolder(link string) (int, error) {
+	folderLink, members, err := Folder(link)
+	if err != nil {
+		return 0, err
+	}
+
+	return pc.Channels.AddFolder(folderLink, members), nil
+}
+
+// Добавление каналов по ссылкам (с раскрытием папок)
+func (pc *Channels) Expand(links []string) (int, error) {
+	var (
+		n    int
+		errs []error
+	)
+
+	for _, link := range links {
+		if check.Addlist(link) != "" {
+			added, err := pc.AddFolder(link)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			n += added
+			continue
+		}
+
+		if ok := pc.Add(link); ok {
+			n++
+		}
+	}
+
+	return n, errors.Join(errs...)
+}
+
+// Добавление каналов из строки (с раскрытием папок)
+func (pc *Channels) ExpandFromString(str string) (int, error) {
+	list, err := check.List(str)
+	if err != nil {
+		return 0, fmt.Errorf("Ошибка списка каналов: %w", err)
+	}
+
+	return pc.Expand(list)
+}
+
+// Добавление каналов из файла (с раскрытием папок)
+func (pc *Channels) ExpandFromFile(filename string) (int, error) {
+	links, err := file.ReadLines(filename)
+	if err != nil {
+		return 0, fmt.Errorf("Ошибка списка каналов: %w", err)
+	}
+
+	return pc.Expand(links)
+}
+
+// Заполнение каналов из параметров: канал, папка, список или тестовый набор
+func (pc *Channels) Fill(peer, folder string, peers []string, isTest bool) (err error) {
+	switch {
+	case peer != "":
+		pc.Add(peer)
+	case folder != "":
+		_, err = pc.AddFolder(folder)
+	case len(peers) > 0:
+		_, err = pc.Expand(peers)
+	case isTest:
+		_, err = pc.ExpandFromFile(TestChannelsFile)
+	}
+
+	return err
+}
@@ -0,0 +1,177 @@
+package parse
+
+import (
+	"reflect"
+	"testing"
+)
+
+var folderMembers = []string{"https://t.me/codecamp", "https://t.me/thecodemedia",
+	"https://t.me/serious_tester", "https://t.me/joinchat/so8YUpEsL4BkZGQy"}
+
+func TestFolder(t *testing.T) {
+	tests := []struct {
+		test       string
+		value      string
+		folderLink string
+		result     []string
+		isError    bool
+	}{
+		{"Tme", "t.me/addlist/ITchannels2023", "https://t.me/addlist/ITchannels2023", folderMembers, false},
+		{"TgLink", "tg://addlist?slug=ITchannels2023", "https://t.me/addlist/ITchannels2023", folderMembers, false},
+		{"Unknown", "t.me/addlist/unknown_folder", "https://t.me/addlist/unknown_folder", nil, true},
+		{"Username", "t.me/username", "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			folderLink, result, err := Folder(tt.value)
+
+			if (err != nil) != tt.isError {
+				t.Fatalf("Получена ошибка: %v, ожидается: %v", err, tt.isError)
+			}
+			if folderLink != tt.folderLink {
+				t.Errorf("Link - получено значение: %v, ожидается: %v", folderLink, tt.folderLink)
+			}
+			if !reflect.DeepEqual(result, tt.result) {
+				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
+			}
+		})
+	}
+}
+
+func TestAddFolder(t *testing.T) {
+	tests := []struct {
+		test    string
+		value   string
+		result  int
+		isError bool
+	}{
+		{"Valid", "t.me/addlist/ITchannels2023", 4, false},
+		{"Unknown", "t.me/addlist/unknown_folder", 0, true},
+		{"Username", "t.me/username", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			channels := NewChannels()
+			result, err := channels.AddFolder(tt.value)
+
+			if (err != nil) != tt.isError {
+				t.Errorf("Получена ошибка: %v, ожидается: %v", err, tt.isError)
+			}
+			if result != tt.result || channels.Count() != tt.result {
+				t.Errorf("Получено значение: %v (%v), ожидается: %v", result, channels.Count(), tt.result)
+			}
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		test    string
+		values  []string
+		result  int
+		isError bool
+	}{
+		{"Valid", []string{"username", "@username", "t.me/username"}, 3, false},
+		{"Folder", []string{"username", "t.me/addlist/ITchannels2023"}, 5, false},
+		{"FolderUnknown", []string{"username", "t.me/addlist/unknown_folder"}, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			channels := NewChannels()
+			result, err := channels.Expand(tt.values)
+
+			if (err != nil) != tt.isError {
+				t.Errorf("Получена ошибка: %v, ожидается: %v", err, tt.isError)
+			}
+			if result != tt.result {
+				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
+			}
+		})
+	}
+}
+
+func TestExpandFromString(t *testing.T) {
+	tests := []struct {
+		test    string
+		value   string
+		result  int
+		isError bool
+	}{
+		{"Valid", "username, t.me/addlist/ITchannels2023", 5, false},
+		{"Empty", "[]", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			channels := NewChannels()
+			result, err := channels.ExpandFromString(tt.value)
+
+			if (err != nil) != tt.isError {
+				t.Errorf("Получена ошибка: %v, ожидается: %v", err, tt.isError)
+			}
+			if result != tt.result {
+				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
+			}
+		})
+	}
+}
+
+func TestExpandFromFile(t *testing.T) {
+	tests := []struct {
+		test    string
+		value   string
+		isError bool
+	}{
+		{"Valid", TestChannelsFile, false},
+		{"Missing", "data/not_existed_channels", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			channels := NewChannels()
+			result, err := channels.ExpandFromFile(tt.value)
+
+			if (err != nil) != tt.isError {
+				t.Errorf("Получена ошибка: %v, ожидается: %v", err, tt.isError)
+			}
+			if (result > 0) == tt.isError {
+				t.Errorf("Получено значение: %v", result)
+			}
+		})
+	}
+}
+
+func TestFill(t *testing.T) {
+	tests := []struct {
+		test    string
+		peer    string
+		folder  string
+		peers   []string
+		isTest  bool
+		result  int
+		isError bool
+	}{
+		{"Channel", "username", "t.me/addlist/ITchannels2023", []string{"username1"}, true, 1, false},
+		{"Folder", "", "t.me/addlist/ITchannels2023", []string{"username1"}, true, 4, false},
+		{"FolderUnknown", "", "t.me/addlist/unknown_folder", nil, false, 0, true},
+		{"Channels", "", "", []string{"username1", "username2"}, true, 2, false},
+		{"Empty", "", "", nil, false, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			channels := NewChannels()
+			err := channels.Fill(tt.peer, tt.folder, tt.peers, tt.isTest)
+
+			if (err != nil) != tt.isError {
+				t.Errorf("Получена ошибка: %v, ожидается: %v", err, tt.isError)
+			}
+			if channels.Count() != tt.result {
+				t.Errorf("Получено значение: %v, ожидается: %v", channels.Count(), tt.result)
+			}
+		})
+	}
+}
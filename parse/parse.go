@@ -9,16 +9,15 @@ import (
 	"sync"
 	"time"
 
-	"statosphere/parser/cache"
 	"statosphere/parser/channel"
 	"statosphere/parser/channels"
 	"statosphere/parser/check"
 	"statosphere/parser/date"
+	"statosphere/parser/flight"
 	"statosphere/parser/format"
 	"statosphere/parser/get"
 	"statosphere/parser/links"
 	"statosphere/parser/message"
-	"statosphere/parser/proxy"
 	"statosphere/parser/regexp"
 	"statosphere/parser/response"
 	"statosphere/parser/value"
@@ -30,6 +29,20 @@ type Channels struct {
 	m sync.Mutex
 }
 
+// Результат получения данных канала
+type fetched struct {
+	info, msgs    response.Response
+	isExact       bool      // Получено точное число подписчиков (или страница информации)
+	messagesCount uint      // Запрошенное число сообщений
+	fetchedAt     time.Time // Время получения последних сообщений
+
+	channel *channel.Channel // Обработанный канал (со статусом и при ошибках)
+	cached  *CachedChannel   // Запись кэша (nil - данные не получены)
+}
+
+// Одновременные получения данных каналов (по каналу и параметрам парсинга)
+var fetches flight.Group[fetched]
+
 // Конструктор набора каналов для парсинга
 func NewChannels() Channels {
 	return Channels{
@@ -39,8 +52,6 @@ func NewChannels() Channels {
 
 // Парсинг каналов
 func (pc *Channels) Parse(ctx context.Context, isExactParticipants bool, messagesCount uint) (int, []error) {
-	const cacheDuration = 5 * time.Minute // Время кэширования
-
 	var (
 		parsed int
 		errs   []error
@@ -56,17 +67,25 @@ func (pc *Channels) Parse(ctx context.Context, isExactParticipants bool, message
 
 	for i, c := range pc.Channels.Channels {
 
-		// Попытка чтения из кэша
-		if value, ok := cache.Value(c.Link); ok {
-			cacheChannel := value.(*channel.Channel)
-			cacheMessagesCount := uint(len(cacheChannel.Messages))
+		// Попытка чтения из кэша (если в кэше не все нужное - дополнение)
+		var entry *CachedChannel
 
-			if messagesCount == cacheMessagesCount {
-				pc.Channels.Channels[i] = cacheChannel
+		if cached, isStale, ok := Cache.GetStale(c.Link); ok {
+			switch {
+			case !isStale && cached.Covers(isExactParticipants, messagesCount):
+				pc.Channels.Channels[i] = cached.View(messagesCount)
 				parsed++
 				continue
-			} else {
-				cache.Remove(c.Link)
+
+			// Устаревшие данные - сразу, а обновление - в фоне
+			case isStale && cached.Contains(isExactParticipants, messagesCount):
+				pc.Channels.Channels[i] = cached.StaleView(messagesCount)
+				revalidate(*cached, isExactParticipants, messagesCount)
+				parsed++
+				continue
+
+			case !isStale:
+				entry = cached
 			}
 		}
 
@@ -74,80 +93,68 @@ func (pc *Channels) Parse(ctx context.Context, isExactParticipants bool, message
 
 		wg.Add(1)
 
-		go func(c *channel.Channel) {
+		go func(c *channel.Channel, entry *CachedChannel) {
 			defer wg.Done()
 
-			var resInfo, resMsgs response.Response
-			var chInfo, chMsgs = make(chan response.Response), make(chan response.Response)
+			var res fetched
 
-			// Если нужно точное число подписчиков
-			if isExactParticipants {
-				go Info(chInfo, *c)
-			} else {
-				go func() { chInfo <- response.Response{} }()
-			}
+			// Одновременный парсинг того же канала с теми же параметрами выполняется один раз
+			key := fmt.Sprintf("%s:%t:%d", c.Peer, isExactParticipants, messagesCount)
+			chFetched := fetches.DoChan(key, func() (fetched, error) {
+				var (
+					res fetched
+					ok  bool
+				)
 
-			// Если нужны сообщения или достаточно приближенного числа подписчиков
-			if c.Username != "" && (messagesCount > 0 || !isExactParticipants) {
-				go Messages(chMsgs, *c, messagesCount, 0, true)
-			} else {
-				go func() { chMsgs <- response.Response{} }()
-			}
+				if entry != nil {
+					res, ok = topUp(*entry, isExactParticipants, messagesCount)
+				}
+				if !ok {
+					res = fetch(*c, isExactParticipants, messagesCount)
+				}
 
-			// Ожидание всех результатов
+				// Обработка полученных данных и запись в кэш (один раз для всех ожидающих)
+				processed := *c
+				res.cached = apply(&processed, res)
+				res.channel = &processed
+
+				return res, nil
+			})
+
+			// Ожидание результата
 			select {
-			case resInfo = <-chInfo:
-				resMsgs = <-chMsgs
+			case fetchedRes := <-chFetched:
+				res = fetchedRes.Value
 			case <-ctx.Done():
+				m.Lock()
 				errs = append(errs, fmt.Errorf("Ошибка парсинга: %w", fmt.Errorf("отмена для %v", c.Peer)))
+				m.Unlock()
 				return
 			}
 
 			pc.m.Lock()
 			defer pc.m.Unlock()
 
-			// Обработка полученных данных
-
-			if resInfo.Ok {
-				infoData := resInfo.Data.(channel.Channel)
-				*c = infoData
-			}
-
-			if resMsgs.Ok {
-				msgsData := resMsgs.Data.(channel.Channel)
-
-				if !resInfo.Ok {
-					*c = msgsData
-				} else {
-					c.Photos = msgsData.Photos
-					c.Videos = msgsData.Videos
-					c.Files = msgsData.Files
-					c.Links = msgsData.Links
-				}
-
-				if len(msgsData.Messages) > 0 {
-					c.Messages = msgsData.Messages
-				}
-			}
-
 			// Обработка результатов и ошибок
 
 			m.Lock()
 			defer m.Unlock()
 
-			if resInfo.Ok || resMsgs.Ok {
-				cache.SetValue(c.Link, c, cacheDuration) // Запись в кэш
+			if res.cached != nil {
+				*c = *res.cached.View(messagesCount) // Нужное число сообщений
 				parsed++
+			} else {
+				*c = *res.channel
 			}
 
-			if resInfo.Error != nil {
-				errs = append(errs, fmt.Errorf("Ошибка парсинга информации: %w", resInfo.Error))
+			if res.info.Error != nil {
+				errs = append(errs, fmt.Errorf("Ошибка парсинга информации: %w", res.info.Error))
 			}
 
-			if resMsgs.Error != nil {
-				errs = append(errs, fmt.Errorf("Ошибка парсинга сообщений: %w", resMsgs.Error))
+			if res.msgs.Error != nil {
+				errs = append(errs, fmt.Errorf("Ошибка парсинга сообщений: %w", res.msgs.Error))
 			}
-		}(c)
+		}(c, entry)
 	}
 
 	wg.Wait()
@@ -155,6 +162,117 @@ func (pc *Channels) Parse(ctx context.Context, isExactParticipants bool, message
 	return parsed, errs
 }
 
+// Обработка полученных данных канала с записью в кэш (nil - данные не получены)
+func apply(c *channel.Channel, res fetched) *CachedChannel {
+	cached := merge(c, res)
+	if cached == nil {
+		return nil
+	}
+
+	Cache.Set(c.Link, cached)
+	persist(cached.Channel)
+
+	return cached
+}
+
+// Обработка полученных данных канала (nil - данные не получены)
+func merge(c *channel.Channel, res fetched) *CachedChannel {
+	resInfo, resMsgs := res.info, res.msgs
+
+	if resInfo.Ok {
+		infoData := resInfo.Data.(channel.Channel)
+		*c = infoData
+	}
+
+	if resMsgs.Ok {
+		msgsData := resMsgs.Data.(channel.Channel)
+
+		if !resInfo.Ok {
+			*c = msgsData
+		} else {
+			c.Photos = msgsData.Photos
+			c.Videos = msgsData.Videos
+			c.Files = msgsData.Files
+			c.Links = msgsData.Links
+		}
+
+		if len(msgsData.Messages) > 0 {
+			c.Messages = msgsData.Messages
+		}
+	}
+
+	// Статус канала (в том числе при ошибках)
+
+	infoStatus, infoRestriction := statusOf(resInfo)
+	msgsStatus, msgsRestriction := statusOf(resMsgs)
+
+	c.Status = mergeStatus(infoStatus, msgsStatus)
+
+	c.Restriction = infoRestriction
+	if c.Restriction == "" {
+		c.Restriction = msgsRestriction
+	}
+
+	if !resInfo.Ok && !resMsgs.Ok {
+		return nil
+	}
+
+	// Запись в кэш (с описанием содержимого)
+	cached := &CachedChannel{
+		Channel:       new(channel.Channel),
+		IsExact:       res.isExact || c.Participants.IsExact(),
+		MessagesCount: res.messagesCount,
+		FetchedAt:     res.fetchedAt,
+	}
+	*cached.Channel = *c
+
+	return cached
+}
+
+// Получение информации и сообщений канала
+func fetch(c channel.Channel, isExactParticipants bool, messagesCount uint) (res fetched) {
+	var chInfo, chMsgs = make(chan response.Response), make(chan response.Response)
+
+	res.messagesCount = messagesCount
+	res.fetchedAt = time.Now()
+	defer func() { res.isExact = res.info.Ok }()
+
+	// Если нужны и точное число подписчиков, и сообщения - сначала сообщения:
+	// на странице сообщений небольших каналов число подписчиков уже точное
+	if isExactParticipants && messagesCount > 0 && c.Username != "" {
+		go Messages(chMsgs, c, messagesCount, 0, true)
+		res.msgs = <-chMsgs
+
+		if data, ok := res.msgs.Data.(channel.Channel); res.msgs.Ok && ok && data.Participants.IsExact() {
+			return res
+		}
+
+		go Info(chInfo, c)
+		res.info = <-chInfo
+
+		return res
+	}
+
+	// Если нужно точное число подписчиков
+	if isExactParticipants {
+		go Info(chInfo, c)
+	} else {
+		go func() { chInfo <- response.Response{} }()
+	}
+
+	// Если нужны сообщения или достаточно приближенного числа подписчиков
+	if c.Username != "" && (messagesCount > 0 || !isExactParticipants) {
+		go Messages(chMsgs, c, messagesCount, 0, true)
+	} else {
+		go func() { chMsgs <- response.Response{} }()
+	}
+
+	res.info = <-chInfo
+	res.msgs = <-chMsgs
+
+	return res
+}
+
 // Печать отчета
 func (pc *Channels) PrintReport(errs []error, isPrintRegexpReport bool) {
 	if errs != nil {
@@ -211,12 +329,15 @@ func (pc *Channels) RemoveUnparsed() (removed int) {
 	return removed
 }
 
-// Удаление каналов без сообщений
+// Удаление каналов без сообщений (кроме каналов с отключенным предпросмотром)
 func (pc *Channels) RemoveUnmessaged() (removed int) {
 	count := pc.Count()
 
 	for i := 0; i < count; i++ {
-		if len(pc.Channels.Channels[i].Messages) == 0 {
+		c := pc.Channels.Channels[i]
+		isPreviewDisabled := c.Status == channel.StatusPreviewDisabled && c.Title != ""
+
+		if len(c.Messages) == 0 && !isPreviewDisabled {
 			pc.RemoveByIdx(i)
 			removed++
 			count--
@@ -227,68 +348,41 @@ func (pc *Channels) RemoveUnmessaged() (removed int) {
 	return removed
 }
 
-// Тестирование прокси
-func (pc *Channels) TestProxy(step, limit uint, pause time.Duration) {
-	var (
-		i     uint
-		count int
-		total int
-		m     sync.Mutex
-		wg    sync.WaitGroup
-	)
-
-	pc.Limit(0, step)
-
-	for i = 0; i < limit; i = i + step {
-		wg.Add(1)
-		count++
-
-		go func(count int) {
-			defer wg.Done()
-
-			proxy := proxy.Current()
-			if proxy == "" {
-				proxy = "-"
-			}
-
-			parsed, _ := pc.Parse(context.Background(), true, 0)
-
-			fmt.Printf("%2d: %3d  %s\n", count, parsed, proxy)
-
-			m.Lock()
-			defer m.Unlock()
-
-			total += parsed
-		}(count)
-
-		time.Sleep(pause)
-	}
-
-	wg.Wait()
-
-	fmt.Printf("\nУспешно: %d / %d (%0.0f%%)\n", total, limit, float64(total)/float64(limit)*100)
-}
-
 // Общие паттерны для парсинга
 const (
-	PatternIsValidInfo  = `tgme_page_(title|extra)`
-	PatternIsValidMsgs  = `tgme_header_(title|counter)`
-	PatternIsntEmpty    = `meta.*?robots.*?no(index|follow)`
-	PatternTitleProp    = `property="(og|twitter):title"[^>]+?content="(?P<title>[^"]+?)"`
-	PatternTitleBody    = `tgme_(page|(channel_info_)?header)_title[^>]+?>([^>]+?>)?(?P<title>.+?)</div`
-	PatternAboutProp    = `property="(og|twitter):description"[^>]+?content="(?P<about>[^"]*?)"`
-	PatternAboutBody    = `tgme_(page|channel_info)_description[^>]+?>(?P<about>.*?)</div`
-	PatternImageProp    = `property="(og|twitter):image".+?content="(?P<image>.+?` + PatternImage + `)?"`
-	PatternImageBody    = `tgme_page_photo_image.+?src="(?P<image>.+?` + PatternImage + `)?"`
-	PatternScamEn       = `warning.+?report.+?(scam|fake).+?account.+?careful.+?money`
-	PatternScamRu       = `внимание.+?жалова.+?(мошенничество|выдать себя).+?аккаунт.+?осторожн.+?ден[еь]г`
-	PatternVerified     = `<i class="verified-icon">`
+	PatternIsValidInfo = `tgme_page_(title|extra)`
+	PatternIsValidMsgs = `tgme_header_(title|counter)`
+	PatternIsntEmpty   = `meta.*?robots.*?no(index|follow)`
+	PatternTitleProp   = `property="(og|twitter):title"[^>]+?content="(?P<title>[^"]+?)"`
+	PatternTitleBody   = `tgme_(page|(channel_info_)?header)_title[^>]+?>([^>]+?>)?(?P<title>.+?)</div`
+	PatternAboutProp   = `property="(og|twitter):description"[^>]+?content="(?P<about>[^"]*?)"`
+	PatternAboutBody   = `tgme_(page|channel_info)_description[^>]+?>(?P<about>.*?)</div`
+	PatternImageProp   = `property="(og|twitter):image".+?content="(?P<image>.+?` + PatternImage + `)?"`
+	PatternImageBody   = `tgme_page_photo_image.+?src="(?P<image>.+?` + PatternImage + `)?"`
+	PatternScamEn      = `warning.+?report.+?(scam|fake).+?account.+?careful.+?money`
+	PatternScamRu      = `внимание.+?жалова.+?(мошенничество|выдать себя).+?аккаунт.+?осторожн.+?ден[еь]г`
+	PatternVerified    = `<i class="verified-icon">`
+	PatternRestriction = `(?i)(?P<reason>(this|the)\s+[a-z]+\s+can(’|'|&#39;)?t\s+be\s+displayed[^<"]*|` +
+		`[а-яё]+\s+[а-яё]+\s+не\s+может\s+быть\s+показан[^<"]*)`
+	PatternMonthlyUsers = `(?i)(monthly\s+users|пользовател[а-я]*\s+в\s+месяц)`
+	PatternBotButton    = `(?i)(start|open)\s+(bot|app)`
 	PatternNumber       = `[\d\s\.,_]+`
 	PatternFactorNumber = `[\d\s\.,_MK]+`
 	PatternImage        = `\.(jpe?g|png)`
 	PatternVideo        = `\.(mp(eg-?)?4|flv)`
 )
 
+// Подписи счетчиков на поддерживаемых языках (en, ru, uk, de, es)
+const (
+	LabelSubscribers = `(?i:subscribers?|подписчик[а-я]*|підписник[а-яіїє]*|abonnent(en)?|suscriptor(es)?)`
+	LabelMembers     = `(?i:members?|участник[а-я]*|учасник[а-яіїє]*|mitglied(er)?|miembros?)`
+	LabelPhotos      = `(?i:photos?|фото|fotos?)`
+	LabelVideos      = `(?i:videos?|видео|відео|v[ií]deos?)`
+	LabelFiles       = `(?i:files?|файл[а-яіїє]*|dateien|datei|archivos?)`
+	LabelLinks       = `(?i:links?|ссыл[а-я]*|посилан[а-яіїє]*|enlaces?)`
+	LabelEdited      = `(?i:edited|изменено|змінено|bearbeitet|editado)`
+)
+
 // Парсинг основной информации канала
 func Info(chRes chan<- response.Response, c channel.Channel) {
 	defer func() {
@@ -298,30 +392,51 @@ func Info(chRes chan<- response.Response, c channel.Channel) {
 		}
 	}()
 
-	channelPeer, channelLink := format.Username(c.Username, c.Joinchat, 0)
+	channelPeer, _ := format.Username(c.Username, c.Joinchat, 0)
 	infoLink, _ := format.PageLink(c.Username, c.Joinchat)
 
 	// Получение страницы
 	code, page, err := get.Page(infoLink)
 	if code != 200 || err != nil {
-		chRes <- response.Response{Ok: false, Code: code, Error: err}
+		c.Status = statusByCode(code)
+		chRes <- response.Response{Ok: false, Code: code, Data: c, Error: err}
 		return
 	}
 
+	// Если канал ограничен
+	c.Restriction = restriction(page)
+
 	// Если страница невалидна
 	re := regexp.Prepare("isValid", PatternIsValidInfo)
 	if !re.Match(page) {
-		chRes <- response.Response{Ok: false, Code: 404, Error: fmt.Errorf("нет данных для %s", channelPeer)}
+		chRes <- notFound(c, channelPeer)
 		return
 	}
 
 	// ... или пуста
 	re = regexp.Prepare("isntEmpty", PatternIsntEmpty)
 	if re.Match(page) {
-		chRes <- response.Response{Ok: false, Code: 404, Error: fmt.Errorf("нет данных для %s", channelPeer)}
+		chRes <- notFound(c, channelPeer)
 		return
 	}
 
+	c = parseInfo(page, c)
+
+	chRes <- response.Response{Ok: true, Code: 200, Data: c, Error: nil}
+}
+
+// Парсинг основной информации канала со страницы
+func parseInfo(page string, c channel.Channel) channel.Channel {
+	var err error
+
+	channelPeer, channelLink := format.Username(c.Username, c.Joinchat, 0)
+
+	// Статус
+	c.Status = channel.StatusOk
+	if c.Restriction != "" {
+		c.Status = channel.StatusRestricted
+	}
+
 	c.Contacts = links.New()
 	c.Siblings = links.New()
 
@@ -334,7 +449,7 @@ func Info(chRes chan<- response.Response, c channel.Channel) {
 	}
 
 	for _, pattern := range patterns {
-		re = regexp.Prepare("title", pattern)
+		re := regexp.Prepare("title", pattern)
 		res := re.Find(page)
 
 		if len(res) > 0 {
@@ -355,7 +470,7 @@ func Info(chRes chan<- response.Response, c channel.Channel) {
 	}
 
 	for _, pattern := range patterns {
-		re = regexp.Prepare("about", pattern)
+		re := regexp.Prepare("about", pattern)
 		res := re.Find(page)
 
 		if len(res) > 0 {
@@ -364,11 +479,16 @@ func Info(chRes chan<- response.Response, c channel.Channel) {
 		}
 	}
 
-	re = regexp.Prepare("isntAbout", `(view|join|contact).+?right`)
+	re := regexp.Prepare("isntAbout", `(view|join|contact).+?right`)
 	if re.Match(c.About) {
 		c.About = ""
 	}
 
+	// Описание ограниченного канала - это причина ограничения
+	if c.Restriction != "" && restriction(c.About) != "" {
+		c.About = ""
+	}
+
 	// Изображение
 
 	patterns = []string{
@@ -386,17 +506,38 @@ func Info(chRes chan<- response.Response, c channel.Channel) {
 		}
 	}
 
-	// Число подписчиков
+	// Число пользователей в месяц (для ботов)
 
-	pattern := `tgme_page_extra.+?>(?P<participants>` + PatternNumber + `).*?</`
+	pattern := `tgme_page_extra[^>]*?>[^<]*?(?P<users>` + PatternNumber + `)` + PatternMonthlyUsers
 
-	re = regexp.Prepare("participants", pattern)
+	re = regexp.Prepare("monthlyUsers", pattern)
 	res := re.Find(page)
 
-	c.Participants, err = value.New(res["participants"], true)
+	c.MonthlyUsers, _ = value.New(exactNumber(res["users"]), true)
 
-	if res["participants"] != "" && err != nil {
-		panic(fmt.Errorf("не получено число подписчиков для %s", channelPeer))
+	// Число подписчиков (сначала с подписью на одном из языков, затем первое число)
+
+	if c.MonthlyUsers.Value() == 0 {
+		patterns = []string{
+			`tgme_page_extra[^>]*?>(?P<participants>` + PatternNumber + `)(` +
+				LabelSubscribers + `|` + LabelMembers + `)`,
+			`tgme_page_extra.+?>(?P<participants>` + PatternNumber + `).*?</`,
+		}
+
+		for i, pattern := range patterns {
+			re = regexp.Prepare("infoParticipants"+fmt.Sprint(i), pattern)
+			res = re.Find(page)
+
+			if len(res) > 0 {
+				break
+			}
+		}
+
+		c.Participants, err = value.New(exactNumber(res["participants"]), true)
+
+		if res["participants"] != "" && err != nil {
+			panic(fmt.Errorf("не получено число подписчиков для %s", channelPeer))
+		}
 	}
 
 	// Кнопка
@@ -413,32 +554,42 @@ func Info(chRes chan<- response.Response, c channel.Channel) {
 	patterns = []string{
 		`tgme_page_context_link.+?href="/s/.+?"`,
 		`(?i)join.+?(channel|(super)?group)`,
-		`(?i)bot(father)?$`,
+		`tgme_page_extra[^>]*?>[^<]*?` + PatternMonthlyUsers,
+		PatternBotButton,
+		`(?i)^botfather$`,
 		`(?i)send.+?message`,
+		`(?i)bot$`,
 	}
 
 	kinds := []string{
 		"channel",
 		"private",
 		"bot",
+		"bot",
+		"bot",
 		"user",
+		"bot",
+	}
+
+	// Юзернеймы ботов всегда оканчиваются на "bot" (кроме @botfather),
+	// но юзернейм проверяется, только если на странице нет разметки
+	// пользователя (юзернейм обычного пользователя тоже может оканчиваться на "bot")
+	wheres := []string{
+		page,
+		button,
+		page,
+		button,
+		c.Username,
+		button,
+		c.Username,
 	}
 
 	for i, pattern := range patterns {
 		kind := kinds[i]
-
-		var where string
-		switch kind {
-		case "channel":
-			where = page
-		case "private", "user":
-			where = button
-		case "bot":
-			where = c.Username
-		}
+		where := wheres[i]
 
 		if !((kind == "user" || kind == "bot") && c.Participants.Value() != 0) {
-			re = regexp.Prepare(kind, pattern)
+			re = regexp.Prepare(kind+fmt.Sprint(i), pattern)
 			if re.Match(where) {
 				c.Kind = kind
 				break
@@ -487,7 +638,84 @@ func Info(chRes chan<- response.Response, c channel.Channel) {
 		}
 	}
 
-	chRes <- response.Response{Ok: true, Code: 200, Data: c, Error: nil}
+	// Описание пользователя - это био
+	if c.Kind == "user" {
+		c.Bio = c.About
+		c.About = ""
+	}
+
+	return c
+}
+
+// Точное число без разделителей разрядов (пробелы, точки и запятые в разных языках)
+func exactNumber(number string) string {
+	return strings.NewReplacer(".", "", ",", "", "_", "").Replace(number)
+}
+
+// Причина ограничения канала
+func restriction(page string) string {
+	re := regexp.Prepare("restriction", PatternRestriction)
+	res := re.Find(page)
+
+	return format.SafeString(res["reason"])
+}
+
+// Статус канала по коду ответа
+func statusByCode(code int) channel.Status {
+	switch code {
+	case 200:
+		return channel.StatusOk
+	case 404:
+		return channel.StatusNotFound
+	case 429:
+		return channel.StatusSuspectedRateLimit
+	}
+
+	return ""
+}
+
+// Ответ для отсутствующего (или ограниченного) канала
+func notFound(c channel.Channel, channelPeer string) response.Response {
+	if c.Restriction != "" {
+		c.Status = channel.StatusRestricted
+		return response.Response{Ok: false, Code: 451, Data: c,
+			Error: fmt.Errorf("канал %s ограничен: %s", channelPeer, c.Restriction)}
+	}
+
+	c.Status = channel.StatusNotFound
+	return response.Response{Ok: false, Code: 404, Data: c, Error: fmt.Errorf("нет данных для %s", channelPeer)}
+}
+
+// Статус канала и причина ограничения из ответа
+func statusOf(res response.Response) (channel.Status, string) {
+	data, ok := res.Data.(channel.Channel)
+	if !ok {
+		return "", ""
+	}
+
+	return data.Status, data.Restriction
+}
+
+// Итоговый статус канала (по информации и сообщениям)
+func mergeStatus(info, msgs channel.Status) channel.Status {
+	switch {
+	case info == channel.StatusRestricted || msgs == channel.StatusRestricted:
+		return channel.StatusRestricted
+	case info == channel.StatusSuspectedRateLimit || msgs == channel.StatusSuspectedRateLimit:
+		return channel.StatusSuspectedRateLimit
+	case info == channel.StatusNotFound && (msgs == channel.StatusOk || msgs == channel.StatusPreviewDisabled),
+		msgs == channel.StatusNotFound && info == channel.StatusOk:
+		// Канал то находится, то нет - похоже на "мягкий" 404 при ограничении запросов
+		return channel.StatusSuspectedRateLimit
+	case msgs == channel.StatusPreviewDisabled:
+		return channel.StatusPreviewDisabled
+	case info == channel.StatusNotFound || msgs == channel.StatusNotFound:
+		return channel.StatusNotFound
+	case info == channel.StatusOk || msgs == channel.StatusOk:
+		return channel.StatusOk
+	}
+
+	return ""
 }
 
 var (
@@ -501,6 +729,21 @@ var (
 
 // Парсинг сообщений канала
 func Messages(chRes chan<- response.Response, c channel.Channel, messagesCount, afterMsgId uint, isParseInfo bool) {
+	messages(chRes, c, messagesCount, afterMsgId, 0, 0, isParseInfo)
+}
+
+// Парсинг сообщений канала, начиная с сообщений до заданного
+func MessagesBefore(chRes chan<- response.Response, c channel.Channel, messagesCount, beforeMsgId uint) {
+	messages(chRes, c, messagesCount, 0, beforeMsgId, 0, false)
+}
+
+// Парсинг последних сообщений канала, до заданного сообщения включительно (от новых к старым)
+func MessagesSince(chRes chan<- response.Response, c channel.Channel, messagesCount, sinceMsgId uint) {
+	messages(chRes, c, messagesCount, 0, 0, sinceMsgId, true)
+}
+
+// Парсинг сообщений канала (после или до заданных сообщений, или последних - до заданного)
+func messages(chRes chan<- response.Response, c channel.Channel, messagesCount, afterMsgId, beforeMsgId, sinceMsgId uint, isParseInfo bool) {
 	defer func() {
 		if err := recover(); err != nil {
 			chRes <- response.Response{Ok: false, Code: 500, Error: err.(error)}
@@ -517,35 +760,64 @@ func Messages(chRes chan<- response.Response, c channel.Channel, messagesCount,
 		return
 	}
 
-	// Отсечка старых сообщений
+	// Отсечка старых (или новых) сообщений
 	initMessagesLink := messagesLink
-	if afterMsgId != 0 {
+	switch {
+	case afterMsgId != 0:
 		initMessagesLink += "?after=" + fmt.Sprint(afterMsgId)
+	case beforeMsgId != 0:
+		initMessagesLink += "?before=" + fmt.Sprint(beforeMsgId)
 	}
 
 	// Получение страницы
 	code, page, err := get.Page(initMessagesLink)
 	if code != 200 || err != nil {
-		chRes <- response.Response{Ok: false, Code: code, Error: err}
+		c.Status = statusByCode(code)
+		chRes <- response.Response{Ok: false, Code: code, Data: c, Error: err}
 		return
 	}
 
 	initPage := page
 
-	// Если страница невалидна
-	re := regexp.Prepare("isValid", PatternIsValidMsgs)
-	if !re.Match(page) {
-		chRes <- response.Response{Ok: false, Code: 404, Error: fmt.Errorf("нет данных для %s", channelPeer)}
+	// Если канал ограничен
+	c.Restriction = restriction(page)
+
+	// Если страница пуста
+	re := regexp.Prepare("isntEmpty", PatternIsntEmpty)
+	if re.Match(page) {
+		chRes <- notFound(c, channelPeer)
 		return
 	}
 
-	// ... или пуста
-	re = regexp.Prepare("isntEmpty", PatternIsntEmpty)
-	if re.Match(page) {
-		chRes <- response.Response{Ok: false, Code: 404, Error: fmt.Errorf("нет данных для %s", channelPeer)}
+	// ... или невалидна
+	re = regexp.Prepare("isValid", PatternIsValidMsgs)
+	if !re.Match(page) {
+
+		// Если предпросмотр отключен, вместо сообщений приходит основная страница
+		re = regexp.Prepare("isValidInfo", PatternIsValidInfo)
+		if !re.Match(page) {
+			chRes <- notFound(c, channelPeer)
+			return
+		}
+
+		if isParseInfo {
+			c = parseInfo(page, c)
+		}
+
+		if c.Status != channel.StatusRestricted {
+			c.Status = channel.StatusPreviewDisabled
+		}
+
+		chRes <- response.Response{Ok: true, Code: 200, Data: c, Error: nil}
 		return
 	}
 
+	// Статус
+	c.Status = channel.StatusOk
+	if c.Restriction != "" {
+		c.Status = channel.StatusRestricted
+	}
+
 	c.Contacts = links.New()
 	c.Siblings = links.New()
 
@@ -566,6 +838,10 @@ func Messages(chRes chan<- response.Response, c channel.Channel, messagesCount,
 				break
 			}
 		}
+
+		if c.Restriction != "" && restriction(c.About) != "" {
+			c.About = ""
+		}
 	}
 
 	// Парсинг основной информации
@@ -618,7 +894,7 @@ func Messages(chRes chan<- response.Response, c channel.Channel, messagesCount,
 		patterns = []string{
 			`tgme_header_counter.+?>(?P<participants>` + PatternFactorNumber + `).*?</`,
 			`tgme_channel_info_counters.+?"counter_value">(?P<participants>` + PatternFactorNumber + `)` +
-				`</[^"]+?"counter_type">[Ss]ubscribers</`,
+				`</[^"]+?"counter_type">(` + LabelSubscribers + `|` + LabelMembers + `)</`,
 			`(?s)tgme_channel_info_counter[^>]+?>([^>]+?>)?(?P<participants>` + PatternFactorNumber + `)</`,
 		}
 
@@ -675,20 +951,33 @@ func Messages(chRes chan<- response.Response, c channel.Channel, messagesCount,
 
 		// Количество фото, видео, файлов и ссылок
 
-		pattern = `tgme_channel_info_counters` +
-			`(.+?"counter_value">(?P<photos>` + PatternFactorNumber + `)</[^"]+?"counter_type">[Pp]hotos</)?` +
-			`(.+?"counter_value">(?P<videos>` + PatternFactorNumber + `)</[^"]+?"counter_type">[Vv]ideos</)?` +
-			`(.+?"counter_value">(?P<files>` + PatternFactorNumber + `)</[^"]+?"counter_type">[Ff]iles</)?` +
-			`(.+?"counter_value">(?P<links>` + PatternFactorNumber + `)</[^"]+?"counter_type">[Ll]inks</)?`
+		pattern = `"counter_value">(?P<value>` + PatternFactorNumber + `)</[^"]+?"counter_type">(?P<type>[^<]+?)</`
 
 		re = regexp.Prepare("extCounts", pattern)
-		res := re.Find(page)
+		counters := re.FindAll(page)
 
-		if len(res) > 0 {
-			c.Photos, _ = value.New(res["photos"], false)
-			c.Videos, _ = value.New(res["videos"], false)
-			c.Files, _ = value.New(res["files"], false)
-			c.Links, _ = value.New(res["links"], false)
+		labels := []string{
+			LabelPhotos,
+			LabelVideos,
+			LabelFiles,
+			LabelLinks,
+		}
+
+		values := []*value.Value{
+			&c.Photos,
+			&c.Videos,
+			&c.Files,
+			&c.Links,
+		}
+
+		for _, counter := range counters {
+			for i, label := range labels {
+				re = regexp.Prepare("extCountType"+fmt.Sprint(i), `^\s*`+label+`\s*$`)
+				if re.Match(counter["type"]) {
+					*values[i], _ = value.New(counter["value"], false)
+					break
+				}
+			}
 		}
 	}
 
@@ -706,13 +995,19 @@ func Messages(chRes chan<- response.Response, c channel.Channel, messagesCount,
 
 	// Парсинг сообщений
 
-	if messagesCount == 0 {
+	if messagesCount == 0 || c.Status == channel.StatusRestricted {
 		chRes <- response.Response{Ok: true, Code: 200, Data: c, Error: nil}
 		return
 	}
 
 	var lastMessageId uint
 
+	// Сообщение, на котором парсинг останавливается
+	stopMsgId := afterMsgId
+	if sinceMsgId > 0 {
+		stopMsgId = sinceMsgId - 1
+	}
+
 	advsCount := make(map[string]int)
 	siblingsThreshold := int(float64(messagesCount) * siblingsThresholdRatio)
 
@@ -776,7 +1071,7 @@ func Messages(chRes chan<- response.Response, c channel.Channel, messagesCount,
 
 			// Отредактировано?
 
-			pattern = `tgme_widget_message_meta.+?>.*?[Ee]dited`
+			pattern = `tgme_widget_message_meta.+?>.*?` + LabelEdited
 
 			re = regexp.Prepare("msgIsEdited", pattern)
 			if re.Match(msg) {
@@ -999,12 +1294,12 @@ func Messages(chRes chan<- response.Response, c channel.Channel, messagesCount,
 			// Добавление сообщения
 			c.Messages = append(c.Messages, nm)
 
-			if len(c.Messages) >= int(messagesCount) || lastMessageId-1 <= afterMsgId || lastMessageId <= 1 {
+			if len(c.Messages) >= int(messagesCount) || lastMessageId-1 <= stopMsgId || lastMessageId <= 1 {
 				break
 			}
 		}
 
-		if len(c.Messages) >= int(messagesCount) || lastMessageId-1 <= afterMsgId || lastMessageId <= 1 {
+		if len(c.Messages) >= int(messagesCount) || lastMessageId-1 <= stopMsgId || lastMessageId <= 1 {
 			break
 		}
 
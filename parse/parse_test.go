@@ -11,7 +11,6 @@ import (
 	"testing"
 	"time"
 
-	"statosphere/parser/cache"
 	"statosphere/parser/channel"
 	"statosphere/parser/channels"
 	"statosphere/parser/get"
@@ -24,7 +23,7 @@ import (
 
 func init() {
 	os.Chdir("..")
-	get.SetTransport("file", 0)
+	get.SetTransport("file")
 }
 
 func TestNewChannels(t *testing.T) {
@@ -94,9 +93,9 @@ func TestParse(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.test, func(t *testing.T) {
-			cache.Disable()
+			Cache.Disable()
 			if tt.isCache {
-				cache.Enable()
+				Cache.Enable()
 			}
 
 			result, errs := tt.value.Parse(tt.ctx, tt.isExact, tt.messages)
@@ -121,6 +120,111 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestParseCoalescing(t *testing.T) {
+	defer Cache.Enable()
+	Cache.Disable()
+
+	// Отмененные в других тестах парсинги завершаются
+	for fetches.InFlight() > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	release := make(chan struct{})
+	title := "Общий результат"
+
+	// Парсинг канала уже выполняется
+	first := fetches.DoChan("@thecodemedia:true:5", func() (fetched, error) {
+		<-release
+		c := channel.Channel{Peer: "@thecodemedia", Title: title, Status: channel.StatusOk}
+		res := fetched{msgs: response.Response{Ok: true, Code: 200, Data: c}}
+		res.cached = apply(&c, res)
+		res.channel = &c
+
+		return res, nil
+	})
+
+	tests := []struct {
+		test     string
+		isExact  bool
+		messages uint
+		result   string
+	}{
+		{"Same", true, 5, title},
+		{"SameToo", true, 5, title},
+		{"OtherOptions", false, 0, "Код"},
+	}
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]string, len(tests))
+	)
+
+	for i, tt := range tests {
+		wg.Add(1)
+
+		go func(i int, isExact bool, messages uint) {
+			defer wg.Done()
+
+			pc := NewChannels()
+			pc.Add("thecodemedia")
+			pc.Parse(context.Background(), isExact, messages)
+
+			results[i] = pc.Channels.Channels[0].Title
+		}(i, tt.isExact, tt.messages)
+	}
+
+	// Все парсинги успевают дождаться уже выполняемого
+	time.Sleep(300 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	<-first
+
+	for i, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			if !strings.Contains(results[i], tt.result) {
+				t.Errorf("Получено значение: %q, ожидается совпадение: %q", results[i], tt.result)
+			}
+		})
+	}
+
+	if n := fetches.InFlight(); n != 0 {
+		t.Errorf("Незавершенных парсингов: %v", n)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	tests := []struct {
+		test     string
+		value    string
+		isExact  bool
+		messages uint
+		isInfo   bool
+		isMsgs   bool
+	}{
+		{"ExactFromMessages", "small_channel", true, 5, false, true},
+		{"ApproxFromMessages", "thecodemedia", true, 5, true, true},
+		{"ExactNoMessages", "thecodemedia", true, 0, true, false},
+		{"NotExact", "thecodemedia", false, 5, false, true},
+		{"PreviewDisabled", "gregory_demons", true, 5, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			pc := NewChannels()
+			pc.Add(tt.value)
+
+			res := fetch(*pc.Channels.Channels[0], tt.isExact, tt.messages)
+
+			if res.info.Ok != tt.isInfo {
+				t.Errorf("Информация - получено значение: %v, ожидается: %v", res.info.Ok, tt.isInfo)
+			}
+			if res.msgs.Ok != tt.isMsgs {
+				t.Errorf("Сообщения - получено значение: %v, ожидается: %v", res.msgs.Ok, tt.isMsgs)
+			}
+		})
+	}
+}
+
 func TestInfo(t *testing.T) {
 	tests := []struct {
 		test         string
@@ -140,47 +244,58 @@ func TestInfo(t *testing.T) {
 		participants value.Value
 		isVerified   bool
 		isScam       bool
+		hasBio       bool
+		monthlyUsers value.Value
 	}{
 		{"Username", "codecamp", true, 200, false, false, "@codecamp",
 			"https://t.me/codecamp", "CodeCamp", true, []string{"@camprobot"},
 			[]string{"t.me/camprobot", "t.me/workcamp"}, true, "channel",
-			value.Value{Exact: 82932}, false, false},
+			value.Value{Exact: 82932}, false, false, false, value.Value{}},
 		{"Joinchat", "+so8YUpEsL4BkZGQy", true, 200, false, false, "+so8YUpEsL4BkZGQy",
 			"https://t.me/joinchat/so8YUpEsL4BkZGQy", "Джейпег Малевича", true,
 			[]string{"t.me/dssale/264"}, []string{"https://t.me/Alivian"}, true, "private",
-			value.Value{Exact: 189490}, false, false},
+			value.Value{Exact: 189490}, false, false, false, value.Value{}},
 		{"Verified", "thecodemedia", true, 200, false, false, "@thecodemedia",
 			"https://t.me/thecodemedia", "Журнал «Код»", true, []string{"thecode.media"}, []string{},
-			true, "channel", value.Value{Exact: 63336}, true, false},
+			true, "channel", value.Value{Exact: 63336}, true, false, false, value.Value{}},
 		{"Scam", "lolz_guru", true, 200, false, false, "@lolz_guru", "https://t.me/lolz_guru",
 			"LOLZTEAM", false, []string{}, []string{}, true, "channel",
-			value.Value{Exact: 163840}, false, true},
+			value.Value{Exact: 163840}, false, true, false, value.Value{}},
 		{"Chat", "ru_python", true, 200, false, false, "@ru_python", "https://t.me/ru_python",
 			"Python", true, []string{"t.me/ru_python/1961404"}, []string{},
-			true, "chat", value.Value{Exact: 14035}, false, false},
+			true, "chat", value.Value{Exact: 14035}, false, false, false, value.Value{}},
 		{"User", "username9", true, 200, false, false, "@username9",
 			"https://t.me/username9", "User", false, []string{}, []string{}, true,
-			"user", value.Value{}, false, false},
+			"user", value.Value{}, false, false, false, value.Value{}},
 		{"Bot", "botfather", true, 200, false, false, "@botfather",
 			"https://t.me/botfather", "BotFather", true, []string{}, []string{}, true,
-			"bot", value.Value{}, true, false},
+			"bot", value.Value{}, true, false, false, value.Value{}},
+		{"UserBio", "sample_user", true, 200, false, false, "@sample_user",
+			"https://t.me/sample_user", "Sample User", false, []string{"@sample_blog"}, []string{},
+			true, "user", value.Value{}, false, false, true, value.Value{}},
+		{"UserBotUsername", "sample_robot", true, 200, false, false, "@sample_robot",
+			"https://t.me/sample_robot", "Sample Robot", false, []string{}, []string{}, true,
+			"user", value.Value{}, false, false, false, value.Value{}},
+		{"BotMonthlyUsers", "sample_bot", true, 200, false, false, "@sample_bot",
+			"https://t.me/sample_bot", "Sample Bot", true, []string{"@sample_support"}, []string{},
+			true, "bot", value.Value{}, false, false, false, value.Value{Exact: 1234567}},
 		{"NotFound", "not_existed_channel", false, 404, true, false, "@not_existed_channel",
 			"https://t.me/not_existed_channel", "", false, []string{}, []string{},
-			false, "", value.Value{}, false, false},
+			false, "", value.Value{}, false, false, false, value.Value{}},
 		{"NotValid", "not_valid_channel", false, 404, true, false, "@not_valid_channel",
 			"https://t.me/not_valid_channel", "", false, []string{}, []string{},
-			false, "", value.Value{}, false, false},
+			false, "", value.Value{}, false, false, false, value.Value{}},
 		{"NoTitle", "seniorpy", false, 500, false, true, "@seniorpy",
 			"https://t.me/seniorpy", "", false, []string{}, []string{},
-			false, "", value.Value{}, false, false},
+			false, "", value.Value{}, false, false, false, value.Value{}},
 		{"WrongParticipants", "netstalkers", false, 500, false, true, "@netstalkers",
 			"https://t.me/netstalkers", "", false, []string{}, []string{},
-			false, "", value.Value{}, false, false},
+			false, "", value.Value{}, false, false, false, value.Value{}},
 		{"NoParticipants", "vtosters", false, 500, false, true, "@vtosters",
 			"https://t.me/vtosters", "", false, []string{}, []string{},
-			false, "", value.Value{}, false, false},
+			false, "", value.Value{}, false, false, false, value.Value{}},
 		{"Empty", "", false, 500, true, false, "", "", "", false, []string{},
-			[]string{}, false, "", value.Value{}, false, false},
+			[]string{}, false, "", value.Value{}, false, false, false, value.Value{}},
 	}
 
 	for _, tt := range tests {
@@ -270,6 +385,13 @@ func TestInfo(t *testing.T) {
 			if c.IsScam != tt.isScam {
 				t.Errorf("IsScam - получено значение: %v, ожидается: %v", c.IsScam, tt.isScam)
 			}
+
+			if hasBio := c.Bio != ""; hasBio != tt.hasBio {
+				t.Errorf("Bio - получено значение: %v, ожидается: %v", hasBio, tt.hasBio)
+			}
+			if !reflect.DeepEqual(c.MonthlyUsers, tt.monthlyUsers) {
+				t.Errorf("MonthlyUsers - получено значение: %#v, ожидается: %#v", c.MonthlyUsers, tt.monthlyUsers)
+			}
 		})
 	}
 }
@@ -600,6 +722,239 @@ func TestMessages(t *testing.T) {
 	}
 }
 
+func TestMessagesCassette(t *testing.T) {
+	defer get.SetTransport("file")
+
+	if err := get.UseCassette("data/cassettes/codecamp.json", get.CassetteReplay); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	tests := []struct {
+		test          string
+		messagesCount uint
+		afterMsgId    uint
+		result        bool
+		code          int
+		count         int
+		first         uint
+		last          uint
+	}{
+		{"OnePage", 10, 0, true, 200, 10, 2376, 2367},
+		{"TwoPages", 30, 0, true, 200, 30, 2376, 2347},
+		{"AllPages", 50, 0, true, 404, 40, 2376, 2337},
+		{"Miss", 10, 2350, false, 500, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			var ch = make(chan response.Response)
+
+			go Messages(ch, channel.Channel{Username: "codecamp"}, tt.messagesCount, tt.afterMsgId, false)
+
+			res := <-ch
+			if res.Ok != tt.result {
+				t.Fatalf("Получен результат: %v, ожидается: %v (ошибка: %v)", res.Ok, tt.result, res.Error)
+			}
+			if res.Code != tt.code {
+				t.Errorf("Code - получено значение: %v, ожидается: %v", res.Code, tt.code)
+			}
+
+			// Запроса нет на кассете - ошибка, а не запрос в сеть
+			if !tt.result {
+				if !errors.Is(res.Error, get.ErrCassetteMiss) {
+					t.Errorf("Получена ошибка: %v, ожидается: %v", res.Error, get.ErrCassetteMiss)
+				}
+				return
+			}
+
+			c := res.Data.(channel.Channel)
+
+			if len(c.Messages) != tt.count {
+				t.Fatalf("Получено сообщений: %v, ожидается: %v", len(c.Messages), tt.count)
+			}
+			if first, last := c.Messages[0].ID, c.Messages[len(c.Messages)-1].ID; first != tt.first || last != tt.last {
+				t.Errorf("Получены ID: %v-%v, ожидаются: %v-%v", first, last, tt.first, tt.last)
+			}
+
+			// Страницы не перекрывают друг друга
+			for i := 1; i < len(c.Messages); i++ {
+				if c.Messages[i].ID != c.Messages[i-1].ID-1 {
+					t.Fatalf("ID не по порядку: %v после %v", c.Messages[i].ID, c.Messages[i-1].ID)
+				}
+			}
+		})
+	}
+}
+
+func TestLocales(t *testing.T) {
+	tests := []struct {
+		test     string
+		value    string
+		isEdited bool // Сообщение 2375 отмечено измененным только в переведенных страницах
+	}{
+		{"English", "codecamp", false},
+		{"Russian", "codecamp_ru", true},
+		{"Ukrainian", "codecamp_uk", true},
+		{"German", "codecamp_de", true},
+		{"Spanish", "codecamp_es", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			var chInfo, chMsgs = make(chan response.Response), make(chan response.Response)
+
+			cc := NewChannels()
+			cc.Add(tt.value)
+
+			c, _ := cc.Find(tt.value)
+
+			go Info(chInfo, *c)
+			go Messages(chMsgs, *c, 10, 2300, true)
+
+			resInfo, resMsgs := <-chInfo, <-chMsgs
+
+			if !resInfo.Ok || !resMsgs.Ok {
+				t.Fatalf("Получены ошибки: %v, %v", resInfo.Error, resMsgs.Error)
+			}
+
+			info := resInfo.Data.(channel.Channel)
+			msgs := resMsgs.Data.(channel.Channel)
+
+			if info.Participants.Value() != 82932 {
+				t.Errorf("Participants (info) - получено значение: %v, ожидается: %v", info.Participants, 82932)
+			}
+			if msgs.Participants.Value() != 82900 {
+				t.Errorf("Participants (msgs) - получено значение: %v, ожидается: %v", msgs.Participants, 82900)
+			}
+
+			counts := []uint{
+				msgs.Photos.Value(),
+				msgs.Videos.Value(),
+				msgs.Files.Value(),
+				msgs.Links.Value(),
+			}
+
+			if !reflect.DeepEqual(counts, []uint{984, 199, 42, 973}) {
+				t.Errorf("Photos, Videos, Files, Links - получены значения: %v, ожидаются: %v",
+					counts, []uint{984, 199, 42, 973})
+			}
+
+			var isEdited bool
+			for _, m := range msgs.Messages {
+				if m.ID == 2375 {
+					isEdited = m.IsEdited
+				}
+			}
+
+			if isEdited != tt.isEdited {
+				t.Errorf("IsEdited - получено значение: %v, ожидается: %v", isEdited, tt.isEdited)
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		test           string
+		value          string
+		isInfo         bool
+		isParseInfo    bool
+		messagesCount  uint
+		result         bool
+		code           int
+		title          string
+		status         channel.Status
+		hasRestriction bool
+		messages       int
+	}{
+		{"InfoOk", "codecamp", true, false, 0, true, 200, "CodeCamp", channel.StatusOk, false, 0},
+		{"InfoNotFound", "not_existed_channel", true, false, 0, false, 404, "", channel.StatusNotFound, false, 0},
+		{"InfoRestricted", "restricted_channel", true, false, 0, true, 200, "Restricted Channel",
+			channel.StatusRestricted, true, 0},
+		{"MessagesOk", "codecamp", false, true, 5, true, 200, "CodeCamp", channel.StatusOk, false, 5},
+		{"MessagesNotFound", "not_existed_channel", false, true, 5, false, 404, "",
+			channel.StatusNotFound, false, 0},
+		{"MessagesRestricted", "restricted_channel", false, true, 5, true, 200, "Restricted Channel",
+			channel.StatusRestricted, true, 0},
+		{"PreviewDisabled", "ru_python", false, true, 5, true, 200, "Python: снова чат",
+			channel.StatusPreviewDisabled, false, 0},
+		{"PreviewDisabledNoInfo", "ru_python", false, false, 5, true, 200, "",
+			channel.StatusPreviewDisabled, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			var ch = make(chan response.Response)
+
+			cc := NewChannels()
+			cc.Add(tt.value)
+
+			c, _ := cc.Find(tt.value)
+
+			if tt.isInfo {
+				go Info(ch, *c)
+			} else {
+				go Messages(ch, *c, tt.messagesCount, 0, tt.isParseInfo)
+			}
+
+			res := <-ch
+			data, _ := res.Data.(channel.Channel)
+
+			if res.Ok != tt.result {
+				t.Fatalf("Получен результат: %v (%v), ожидается: %v", res.Ok, res.Error, tt.result)
+			}
+			if res.Code != tt.code {
+				t.Errorf("Code - получено значение: %v, ожидается: %v", res.Code, tt.code)
+			}
+			if data.Title != tt.title {
+				t.Errorf("Title - получено значение: %q, ожидается: %q", data.Title, tt.title)
+			}
+			if data.Status != tt.status {
+				t.Errorf("Status - получено значение: %v, ожидается: %v", data.Status, tt.status)
+			}
+			if hasRestriction := data.Restriction != ""; hasRestriction != tt.hasRestriction {
+				t.Errorf("Restriction - получено значение: %q, ожидается: %v", data.Restriction, tt.hasRestriction)
+			}
+			if data.About != "" && restriction(data.About) != "" {
+				t.Errorf("About - получено значение: %q, ожидается: без причины ограничения", data.About)
+			}
+			if len(data.Messages) != tt.messages {
+				t.Errorf("Messages - получено количество: %v, ожидается: %v", len(data.Messages), tt.messages)
+			}
+		})
+	}
+}
+
+func TestMergeStatus(t *testing.T) {
+	tests := []struct {
+		test   string
+		info   channel.Status
+		msgs   channel.Status
+		result channel.Status
+	}{
+		{"Ok", channel.StatusOk, channel.StatusOk, channel.StatusOk},
+		{"OnlyInfo", channel.StatusOk, "", channel.StatusOk},
+		{"OnlyMessages", "", channel.StatusOk, channel.StatusOk},
+		{"NotFound", channel.StatusNotFound, channel.StatusNotFound, channel.StatusNotFound},
+		{"OnlyNotFound", "", channel.StatusNotFound, channel.StatusNotFound},
+		{"Restricted", channel.StatusOk, channel.StatusRestricted, channel.StatusRestricted},
+		{"PreviewDisabled", channel.StatusOk, channel.StatusPreviewDisabled, channel.StatusPreviewDisabled},
+		{"RateLimit", channel.StatusSuspectedRateLimit, channel.StatusOk, channel.StatusSuspectedRateLimit},
+		{"SoftNotFound", channel.StatusOk, channel.StatusNotFound, channel.StatusSuspectedRateLimit},
+		{"Empty", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			result := mergeStatus(tt.info, tt.msgs)
+
+			if result != tt.result {
+				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
+			}
+		})
+	}
+}
+
 func TestPrintReport(t *testing.T) {
 	valid := NewChannels()
 
@@ -749,6 +1104,14 @@ func TestRemoveUnmessaged(t *testing.T) {
 
 	valid.Channels.Channels[0].Messages = []*message.Message{{ID: 1, MessageHtml: "A"}}
 
+	previewDisabled := NewChannels()
+
+	previewDisabled.Add("ru_python")
+	previewDisabled.Add("codecamp")
+
+	previewDisabled.Channels.Channels[0].Title = "Python"
+	previewDisabled.Channels.Channels[0].Status = channel.StatusPreviewDisabled
+
 	empty := NewChannels()
 
 	tests := []struct {
@@ -758,6 +1121,7 @@ func TestRemoveUnmessaged(t *testing.T) {
 		count  int
 	}{
 		{"Valid", &valid, 1, 1},
+		{"PreviewDisabled", &previewDisabled, 1, 1},
 		{"Empty", &empty, 0, 0},
 	}
 
@@ -776,36 +1140,6 @@ func TestRemoveUnmessaged(t *testing.T) {
 	}
 }
 
-func TestTestProxy(t *testing.T) {
-	empty := NewChannels()
-
-	tests := []struct {
-		test   string
-		value  *Channels
-		step   uint
-		limit  uint
-		pause  time.Duration
-		result []string
-	}{
-		{"Empty", &empty, 1, 5, 0, []string{"1:", "2:", "3:", "4:", "5:", "-", "0%"}},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.test, func(t *testing.T) {
-			result := mock.ReadOutput(func() {
-				tt.value.TestProxy(tt.step, tt.limit, tt.pause)
-			})
-
-			for _, match := range tt.result {
-				if !strings.Contains(result, match) {
-					t.Fatalf("Получено значение: %q, ожидаются совпадения: %q, не найдено: %q",
-						result, tt.result, match)
-				}
-			}
-		})
-	}
-}
-
 func TestSplitMessages(t *testing.T) {
 	text := "...<div class=\"tgme_widget_message_wrap\">\nMessage 1\n</div>" +
 		"<div class=\"tgme_widget_message_wrap message\">\nMessage 2\n</div>" +
@@ -0,0 +1,47 @@
+package parse
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"statosphere/parser/channel"
+	"statosphere/parser/storage"
+)
+
+// Хранилище спарсенных каналов
+type store struct {
+	store storage.Store
+	m     sync.RWMutex
+}
+
+// Объект хранилища (nil - каналы не сохраняются)
+var st store
+
+// Запись хранилища спарсенных каналов (в консоли и режиме сервера)
+func SetStore(s storage.Store) {
+	st.m.Lock()
+	defer st.m.Unlock()
+
+	st.store = s
+}
+
+// Получение хранилища спарсенных каналов
+func Store() storage.Store {
+	st.m.RLock()
+	defer st.m.RUnlock()
+
+	return st.store
+}
+
+// Сохранение полученного канала (ошибка хранилища не мешает парсингу)
+func persist(c *channel.Channel) {
+	s := Store()
+	if s == nil {
+		return
+	}
+
+	if err := storage.Save(s, c, time.Now()); err != nil {
+		log.Printf("Ошибка сохранения канала %s: %v", c.Peer, err)
+	}
+}
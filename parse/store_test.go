@@ -0,0 +1,126 @@
+package parse
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"statosphere/parser/channel"
+	"statosphere/parser/get"
+	"statosphere/parser/storage"
+)
+
+// Хранилище с подсчетом записей каналов (первая запись ждет разрешения)
+type countingStore struct {
+	*storage.File
+	release chan struct{}
+	upserts int
+	m       sync.Mutex
+}
+
+func (s *countingStore) UpsertChannel(c *channel.Channel) error {
+	<-s.release
+
+	s.m.Lock()
+	s.upserts++
+	s.m.Unlock()
+
+	return s.File.UpsertChannel(c)
+}
+
+func TestParseStore(t *testing.T) {
+	defer get.SetTransport("file")
+	defer SetStore(nil)
+
+	if err := get.UseCassette("data/cassettes/codecamp.json", get.CassetteReplay); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	s, err := storage.OpenFile(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	defer s.Close()
+
+	SetStore(s)
+
+	for i := 0; i < 2; i++ {
+		pc := NewChannels()
+		pc.Add("codecamp")
+
+		if parsed, errs := pc.Parse(context.Background(), false, 20); parsed != 1 {
+			t.Fatalf("Получено значение: %v, ожидается: %v (ошибки: %v)", parsed, 1, errs)
+		}
+	}
+
+	c, ok, err := s.Channel("@codecamp")
+	if err != nil || !ok || c.Title == "" {
+		t.Fatalf("Канал не сохранен: %v %v", c, err)
+	}
+
+	messages, err := s.Messages("@codecamp", time.Time{}, time.Time{})
+	if err != nil || len(messages) != 20 || messages[0].ID != 2376 {
+		t.Errorf("Получено сообщений: %v (%v)", len(messages), err)
+	}
+
+	// Повторный парсинг с теми же данными снимки не дублирует
+	snapshots, _ := s.Snapshots("@codecamp", time.Time{}, time.Time{})
+	if len(snapshots) == 0 || len(snapshots) > 21 {
+		t.Errorf("Получено снимков: %v", len(snapshots))
+	}
+}
+
+func TestParseStoreCoalescing(t *testing.T) {
+	defer get.SetTransport("file")
+	defer SetStore(nil)
+	defer Cache.Enable()
+
+	if err := get.UseCassette("data/cassettes/codecamp.json", get.CassetteReplay); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	Cache.Disable()
+
+	f, err := storage.OpenFile(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	defer f.Close()
+
+	s := &countingStore{File: f, release: make(chan struct{})}
+	SetStore(s)
+
+	// Парсинги, присоединившиеся к выполняемому, канал повторно не записывают
+	var (
+		wg     sync.WaitGroup
+		titles = make([]string, 3)
+	)
+
+	for i := range titles {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			pc := NewChannels()
+			pc.Add("codecamp")
+			pc.Parse(context.Background(), false, 5)
+
+			titles[i] = pc.Channels.Channels[0].Title
+		}(i)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	close(s.release)
+	wg.Wait()
+
+	if s.upserts != 1 {
+		t.Errorf("Получено записей канала: %v, ожидается: %v", s.upserts, 1)
+	}
+	for _, title := range titles {
+		if title == "" {
+			t.Errorf("Канал не спарсен: %v", titles)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package parse
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"statosphere/parser/channel"
+	"statosphere/parser/message"
+	"statosphere/parser/response"
+	"statosphere/parser/storage"
+)
+
+// Параметры синхронизации по умолчанию
+const (
+	SyncRefreshCount uint = 20  // Последние известные сообщения, у которых обновляются просмотры
+	SyncMaxRefresh   uint = 200 // Предельное число известных сообщений, у которых обновляются просмотры
+	SyncMaxMessages  uint = 200 // Число новых сообщений, получаемых от последних (остальные догружаются постранично)
+)
+
+// Число новых сообщений, получаемых от последних (в тестах меньше)
+var syncMaxMessages = SyncMaxMessages
+
+// Ошибка синхронизации без хранилища (последние сообщения каналов берутся из него)
+var ErrNoStore = errors.New("хранилище не задано")
+
+// Изменения канала после синхронизации
+type Delta struct {
+	Peer     string             `json:"peer"`
+	LastID   uint               `json:"lastId"`  // Последнее известное сообщение до синхронизации
+	New      []*message.Message `json:"new"`     // Новые сообщения (от новых к старым)
+	Updated  []*message.Message `json:"updated"` // Измененные известные сообщения (просмотры, текст)
+	Removed  []uint             `json:"removed"` // Известные сообщения, которых больше нет
+	SyncedAt time.Time          `json:"syncedAt"`
+}
+
+// Синхронизация каналов: новые сообщения и обновление последних известных (с записью в хранилище)
+func (pc *Channels) Sync(ctx context.Context, refreshCount uint) ([]Delta, []error) {
+	var (
+		deltas []Delta
+		errs   []error
+		wg     sync.WaitGroup
+		m      sync.Mutex
+	)
+
+	s := Store()
+	if s == nil {
+		return nil, []error{fmt.Errorf("Ошибка синхронизации: %w", ErrNoStore)}
+	}
+
+	if pc.Count() == 0 {
+		return nil, []error{fmt.Errorf("Ошибка синхронизации: %w", errors.New("нет каналов"))}
+	}
+
+	if refreshCount > SyncMaxRefresh {
+		refreshCount = SyncMaxRefresh
+	}
+
+	for _, c := range pc.Channels.Channels {
+		wg.Add(1)
+
+		go func(c channel.Channel) {
+			defer wg.Done()
+
+			var (
+				delta Delta
+				err   = ctx.Err()
+			)
+
+			if err == nil {
+				delta, err = syncChannel(ctx, s, c, refreshCount)
+			}
+
+			m.Lock()
+			defer m.Unlock()
+
+			if err != nil {
+				errs = append(errs, fmt.Errorf("Ошибка синхронизации %s: %w", c.Peer, err))
+				return
+			}
+
+			deltas = append(deltas, delta)
+		}(*c)
+	}
+
+	wg.Wait()
+
+	// Порядок каналов - как в наборе
+	order := make(map[string]int, pc.Count())
+	for i, c := range pc.Channels.Channels {
+		order[c.Peer] = i
+	}
+
+	sort.Slice(deltas, func(i, j int) bool {
+		return order[deltas[i].Peer] < order[deltas[j].Peer]
+	})
+
+	return deltas, errs
+}
+
+// Периодическая синхронизация каналов (снимки подписчиков и просмотров в хранилище), возвращает остановку
+func (pc *Channels) SyncEvery(interval time.Duration, refreshCount uint, onError func(error)) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		for {
+			select {
+			case <-time.After(interval):
+				_, errs := pc.Sync(ctx, refreshCount)
+				for _, err := range errs {
+					if onError != nil && ctx.Err() == nil {
+						onError(err)
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-stopped
+	}
+}
+
+// Синхронизация канала (от последних сообщений до самого старого из обновляемых, удаленные отмечаются в хранилище)
+func syncChannel(ctx context.Context, s storage.Store, c channel.Channel, refreshCount uint) (Delta, error) {
+	delta := Delta{
+		Peer:     c.Peer,
+		New:      []*message.Message{},
+		Updated:  []*message.Message{},
+		Removed:  []uint{},
+		SyncedAt: time.Now(),
+	}
+
+	known, err := s.Messages(c.Peer, time.Time{}, time.Time{})
+	if err != nil {
+		return delta, err
+	}
+
+	sort.Slice(known, func(i, j int) bool {
+		return known[i].ID > known[j].ID
+	})
+
+	// Без известных сообщений - только последние, иначе - все новые и заданное число известных
+	messagesCount, sinceMsgId := refreshCount, uint(0)
+	if messagesCount == 0 {
+		messagesCount = messagesPerPage
+	}
+
+	var window []*message.Message
+
+	if len(known) > 0 {
+		delta.LastID = known[0].ID
+		messagesCount = syncMaxMessages + refreshCount
+
+		if uint(len(known)) > refreshCount {
+			window = known[:refreshCount]
+		} else {
+			window = known
+		}
+
+		sinceMsgId = delta.LastID + 1
+		if len(window) > 0 {
+			sinceMsgId = window[len(window)-1].ID
+		}
+	}
+
+	chMsgs := make(chan response.Response)
+	go MessagesSince(chMsgs, c, messagesCount, sinceMsgId)
+
+	res := <-chMsgs
+	if !res.Ok {
+		if res.Error != nil {
+			return delta, res.Error
+		}
+		return delta, fmt.Errorf("код ответа %d", res.Code)
+	}
+
+	data := res.Data.(channel.Channel)
+
+	// Новых сообщений больше, чем получено от последних - пропущенные догружаются после известных
+	if len(known) > 0 && uint(len(data.Messages)) >= messagesCount {
+		if err := fillGap(ctx, c, &data, sinceMsgId); err != nil {
+			return delta, err
+		}
+	}
+
+	// Новые и измененные сообщения
+	var (
+		byId    = make(map[uint]*message.Message, len(window))
+		fetched = make(map[uint]bool, len(data.Messages))
+		minId   uint
+	)
+
+	for _, m := range window {
+		byId[m.ID] = m
+	}
+
+	for _, m := range data.Messages {
+		fetched[m.ID] = true
+		if minId == 0 || m.ID < minId {
+			minId = m.ID
+		}
+
+		k, ok := byId[m.ID]
+
+		switch {
+		case m.ID > delta.LastID:
+			delta.New = append(delta.New, m)
+		case ok && isChanged(k, m):
+			delta.Updated = append(delta.Updated, m)
+		}
+	}
+
+	// Удаленные сообщения - известные в пределах полученных, но не полученные
+	if len(data.Messages) > 0 {
+		for _, m := range window {
+			if m.ID >= minId && !fetched[m.ID] {
+				delta.Removed = append(delta.Removed, m.ID)
+			}
+		}
+	}
+
+	if err := storage.Save(s, &data, delta.SyncedAt); err != nil {
+		return delta, err
+	}
+
+	return delta, s.RemoveMessages(c.Peer, delta.Removed)
+}
+
+// Догрузка сообщений от заданного до самого старого из полученных (постранично, от старых к новым)
+func fillGap(ctx context.Context, c channel.Channel, data *channel.Channel, sinceMsgId uint) error {
+	minId := data.Messages[0].ID
+	for _, m := range data.Messages {
+		if m.ID < minId {
+			minId = m.ID
+		}
+	}
+
+	for afterMsgId := sinceMsgId - 1; afterMsgId+1 < minId; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		page, ok := messagesOf(c, messagesPerPage, afterMsgId, 0)
+		if !ok {
+			return fmt.Errorf("сообщения после %d не получены", afterMsgId)
+		}
+
+		// Сообщений после заданного больше нет
+		next := afterMsgId
+		for _, m := range page.Messages {
+			if m.ID > next {
+				next = m.ID
+			}
+		}
+		if next == afterMsgId {
+			return nil
+		}
+
+		data.Messages = mergeMessages(data.Messages, page.Messages)
+		afterMsgId = next
+	}
+
+	return nil
+}
+
+// Изменилось ли сообщение (просмотры, текст или отметка редактирования)
+func isChanged(known, m *message.Message) bool {
+	return known.Views != m.Views || known.MessageHtml != m.MessageHtml || known.IsEdited != m.IsEdited
+}
+
+// Печать изменений каналов
+func PrintDeltas(deltas []Delta) {
+	for _, d := range deltas {
+		fmt.Printf("%s (последнее сообщение %d): новых %d, обновленных %d, удаленных %d\n",
+			d.Peer, d.LastID, len(d.New), len(d.Updated), len(d.Removed))
+	}
+	fmt.Println()
+}
+
+// Печать изменений каналов в JSON
+func PrintDeltasJSON(deltas []Delta) error {
+	data, err := json.MarshalIndent(deltas, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(data))
+
+	return nil
+}
@@ -0,0 +1,229 @@
+package parse
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"statosphere/parser/get"
+	"statosphere/parser/message"
+	"statosphere/parser/storage"
+	"statosphere/parser/value"
+)
+
+func TestSync(t *testing.T) {
+	defer get.SetTransport("file")
+	defer SetStore(nil)
+
+	// Без хранилища
+	SetStore(nil)
+
+	pc := NewChannels()
+	pc.Add("codecamp")
+
+	if _, errs := pc.Sync(context.Background(), 5); len(errs) != 1 || !errors.Is(errs[0], ErrNoStore) {
+		t.Fatalf("Получено значение: %v, ожидается: %v", errs, ErrNoStore)
+	}
+
+	// Известные сообщения - до 2373 (2372 с другими просмотрами)
+	if err := get.UseCassette("data/cassettes/codecamp.json", get.CassetteReplay); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	s, err := storage.OpenFile(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	defer s.Close()
+
+	SetStore(s)
+
+	first, errs := pc.Sync(context.Background(), 20)
+	if len(errs) > 0 || len(first) != 1 || len(first[0].New) != 20 || first[0].LastID != 0 {
+		t.Fatalf("Получено значение: %v (ошибки: %v)", first, errs)
+	}
+
+	var known []*message.Message
+	for _, m := range first[0].New {
+		if m.ID <= 2373 {
+			known = append(known, m)
+		}
+	}
+
+	changed := *known[1]
+	changed.Views, _ = value.New("1", true)
+	known[1] = &changed
+
+	s, err = storage.OpenFile(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	defer s.Close()
+
+	// Просмотры хранятся в снимках
+	var snapshots []storage.Snapshot
+	for _, m := range known {
+		snapshots = append(snapshots, storage.Snapshot{Peer: "@codecamp", MessageID: m.ID, Value: m.Views, Time: time.Now()})
+	}
+
+	if err := s.UpsertMessages("@codecamp", known); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	if err := s.AppendSnapshots(snapshots...); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	SetStore(s)
+
+	// Сообщение 2370 исчезло
+	if err := get.UseCassette("data/cassettes/codecamp_sync.json", get.CassetteReplay); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	deltas, errs := pc.Sync(context.Background(), 5)
+	if len(errs) > 0 || len(deltas) != 1 {
+		t.Fatalf("Получено значение: %v (ошибки: %v)", deltas, errs)
+	}
+
+	ids := func(msgs []*message.Message) (res []uint) {
+		for _, m := range msgs {
+			res = append(res, m.ID)
+		}
+		return res
+	}
+
+	d := deltas[0]
+
+	tests := []struct {
+		test   string
+		value  any
+		result any
+	}{
+		{"Peer", d.Peer, "@codecamp"},
+		{"LastID", d.LastID, uint(2373)},
+		{"New", ids(d.New), []uint{2376, 2375, 2374}},
+		{"Updated", ids(d.Updated), []uint{2372}},
+		{"Removed", d.Removed, []uint{2370}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.value, tt.result) {
+				t.Errorf("Получено значение: %v, ожидается: %v", tt.value, tt.result)
+			}
+		})
+	}
+
+	// Новые и обновленные сообщения записаны в хранилище, удаленное - отмечено
+	messages, _ := s.Messages("@codecamp", time.Time{}, time.Time{})
+	if len(messages) == 0 || messages[0].ID != 2376 {
+		t.Errorf("Получено сообщений: %v", len(messages))
+	}
+	for _, m := range messages {
+		if m.ID == 2370 {
+			t.Errorf("Удаленное сообщение в хранилище: %v", m.ID)
+		}
+	}
+
+	// Повторная синхронизация (удаленное сообщение в пределах обновляемых) не сообщает об удалении снова
+	deltas, errs = pc.Sync(context.Background(), 10)
+	if len(errs) > 0 || len(deltas) != 1 || len(deltas[0].Removed) != 0 || len(deltas[0].New) != 0 {
+		t.Errorf("Получено значение: %+v (ошибки: %v)", deltas, errs)
+	}
+}
+
+func TestSyncGap(t *testing.T) {
+	defer get.SetTransport("file")
+	defer SetStore(nil)
+
+	defer func(n uint) { syncMaxMessages = n }(syncMaxMessages)
+	syncMaxMessages = 5
+
+	if err := get.UseCassette("data/cassettes/codecamp.json", get.CassetteReplay); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	s, err := storage.OpenFile(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	defer s.Close()
+
+	// Известные сообщения - до 2360, новых (16) больше, чем получается от последних
+	var known []*message.Message
+	for id := uint(2360); id >= 2357; id-- {
+		known = append(known, &message.Message{ID: id, Date: time.Now()})
+	}
+
+	if err := s.UpsertMessages("@codecamp", known); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	SetStore(s)
+
+	pc := NewChannels()
+	pc.Add("codecamp")
+
+	deltas, errs := pc.Sync(context.Background(), 2)
+	if len(errs) > 0 || len(deltas) != 1 {
+		t.Fatalf("Получено значение: %v (ошибки: %v)", deltas, errs)
+	}
+
+	var ids []uint
+	for _, m := range deltas[0].New {
+		ids = append(ids, m.ID)
+	}
+
+	var result []uint
+	for id := uint(2376); id > 2360; id-- {
+		result = append(result, id)
+	}
+
+	if !reflect.DeepEqual(ids, result) {
+		t.Errorf("Получено значение: %v, ожидается: %v", ids, result)
+	}
+
+	// Пропуска в хранилище нет
+	if messages, _ := s.Messages("@codecamp", time.Time{}, time.Time{}); len(messages) != 20 {
+		t.Errorf("Получено сообщений: %v, ожидается: %v", len(messages), 20)
+	}
+}
+
+func TestSyncEvery(t *testing.T) {
+	defer get.SetTransport("file")
+	defer SetStore(nil)
+
+	if err := get.UseCassette("data/cassettes/codecamp.json", get.CassetteReplay); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	s, err := storage.OpenFile(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	defer s.Close()
+
+	SetStore(s)
+
+	pc := NewChannels()
+	pc.Add("codecamp")
+
+	stop := pc.SyncEvery(10*time.Millisecond, 5, func(err error) { t.Error(err) })
+
+	// Снимок подписчиков канала появляется после первой синхронизации
+	deadline := time.Now().Add(time.Second)
+	for {
+		snapshots, _ := s.Snapshots("@codecamp", time.Time{}, time.Time{})
+		if len(snapshots) > 0 && snapshots[0].MessageID == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Снимки не записаны: %v", snapshots)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	stop()
+}
@@ -0,0 +1,337 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"statosphere/parser/file"
+)
+
+// Параметры замера прокси
+type BenchmarkOptions struct {
+	Requests    int           // Число запросов через каждый прокси
+	Concurrency int           // Число одновременных запросов через каждый прокси
+	Pause       time.Duration // Пауза между запросами одного потока
+}
+
+// Пороги, при которых прокси считается годным
+type Thresholds struct {
+	MinSuccessRate      float64       // Минимальная доля успешных запросов
+	MaxSoftNotFoundRate float64       // Максимальная доля "мягких" 404
+	MaxP95              time.Duration // Максимальная задержка 95% запросов
+}
+
+// Пороги по умолчанию
+var DefaultThresholds = Thresholds{
+	MinSuccessRate:      0.8,
+	MaxSoftNotFoundRate: 0.2,
+	MaxP95:              5 * time.Second,
+}
+
+// Результат замера прокси
+type Result struct {
+	address          string
+	Proxy            string         `json:"proxy"`
+	Requests         int            `json:"requests"`
+	Successes        int            `json:"successes"`
+	SoftNotFounds    int            `json:"softNotFounds"`
+	Failures         int            `json:"failures"`
+	SuccessRate      float64        `json:"successRate"`
+	SoftNotFoundRate float64        `json:"softNotFoundRate"`
+	P50              time.Duration  `json:"-"`
+	P95              time.Duration  `json:"-"`
+	P50Ms            int64          `json:"p50Ms"`
+	P95Ms            int64          `json:"p95Ms"`
+	Errors           map[string]int `json:"errors,omitempty"`
+	RatePerMinute    float64        `json:"ratePerMinute"`
+	IsPassed         bool           `json:"isPassed"`
+}
+
+// Замер одного запроса
+type sample struct {
+	at             time.Duration
+	latency        time.Duration
+	isSoftNotFound bool
+	err            error
+}
+
+// Замер прокси (каждый прокси проверяется отдельно, все - параллельно),
+// результаты - по убыванию доли успешных запросов
+func Benchmark(proxies []string, check ProbeFunc, options BenchmarkOptions, thresholds Thresholds) []Result {
+	if options.Requests < 1 {
+		options.Requests = 1
+	}
+	if options.Concurrency < 1 {
+		options.Concurrency = 1
+	}
+
+	var (
+		results = make([]Result, len(proxies))
+		wg      sync.WaitGroup
+	)
+
+	for i, proxy := range proxies {
+		wg.Add(1)
+
+		go func(i int, proxy string) {
+			defer wg.Done()
+
+			results[i] = measure(proxy, check, options).evaluate(thresholds)
+		}(i, proxy)
+	}
+
+	wg.Wait()
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].SuccessRate != results[j].SuccessRate {
+			return results[i].SuccessRate > results[j].SuccessRate
+		}
+		return results[i].P50 < results[j].P50
+	})
+
+	return results
+}
+
+// Замер одного прокси
+func measure(proxy string, check ProbeFunc, options BenchmarkOptions) Result {
+	var (
+		samples = make([]sample, 0, options.Requests)
+		jobs    = make(chan struct{}, options.Requests)
+		start   = time.Now()
+		m       sync.Mutex
+		wg      sync.WaitGroup
+	)
+
+	for i := 0; i < options.Requests; i++ {
+		jobs <- struct{}{}
+	}
+	close(jobs)
+
+	for i := 0; i < options.Concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for range jobs {
+				latency, isSoftNotFound, err := check(proxy)
+
+				m.Lock()
+				samples = append(samples, sample{time.Since(start), latency, isSoftNotFound, err})
+				m.Unlock()
+
+				time.Sleep(options.Pause)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return summarize(proxy, samples, time.Since(start))
+}
+
+// Сведение замеров в результат
+func summarize(proxy string, samples []sample, elapsed time.Duration) Result {
+	r := Result{
+		address:  proxy,
+		Proxy:    Redact(proxy),
+		Requests: len(samples),
+		Errors:   make(map[string]int),
+	}
+	if r.Proxy == "" {
+		r.Proxy = "direct"
+	}
+
+	var (
+		latencies []time.Duration
+		firstSoft time.Duration
+		beforeIt  int
+	)
+
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].at < samples[j].at
+	})
+
+	for i, s := range samples {
+		switch {
+		case s.err != nil:
+			r.Failures++
+			r.Errors[ErrorClass(s.err)]++
+			continue
+		case s.isSoftNotFound:
+			r.SoftNotFounds++
+			if firstSoft == 0 {
+				firstSoft, beforeIt = s.at, i
+			}
+		default:
+			r.Successes++
+		}
+
+		latencies = append(latencies, s.latency)
+	}
+
+	if r.Requests > 0 {
+		r.SuccessRate = float64(r.Successes) / float64(r.Requests)
+		r.SoftNotFoundRate = float64(r.SoftNotFounds) / float64(r.Requests)
+	}
+
+	r.P50 = percentile(latencies, 0.5)
+	r.P95 = percentile(latencies, 0.95)
+	r.P50Ms = r.P50.Milliseconds()
+	r.P95Ms = r.P95.Milliseconds()
+
+	// Устойчивый темп: до первой "мягкой" 404 (Телеграм начал ограничивать) или весь замер
+	switch {
+	case firstSoft > 0 && beforeIt > 0:
+		r.RatePerMinute = float64(beforeIt) / firstSoft.Minutes()
+	case firstSoft == 0 && elapsed > 0:
+		r.RatePerMinute = float64(r.Successes) / elapsed.Minutes()
+	}
+
+	return r
+}
+
+// Проверка результата по порогам
+func (r Result) evaluate(thresholds Thresholds) Result {
+	r.IsPassed = r.Requests > 0 &&
+		r.SuccessRate >= thresholds.MinSuccessRate &&
+		r.SoftNotFoundRate <= thresholds.MaxSoftNotFoundRate &&
+		(thresholds.MaxP95 == 0 || r.P95 <= thresholds.MaxP95)
+
+	return r
+}
+
+// Процентиль задержек (по ближайшему рангу)
+func percentile(latencies []time.Duration, q float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+
+	sorted := append([]time.Duration{}, latencies...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	idx := int(q*float64(len(sorted))+0.999999) - 1
+	if idx < 0 {
+		idx = 0
+	}
+
+	return sorted[idx]
+}
+
+// Класс ошибки запроса (для сводки)
+func ErrorClass(err error) string {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "timeout"
+	}
+
+	text := strings.ToLower(err.Error())
+
+	switch {
+	case strings.Contains(text, "timeout") || strings.Contains(text, "таймаут"):
+		return "timeout"
+	case strings.Contains(text, "407") || strings.Contains(text, "authentication") || strings.Contains(text, "username/password"):
+		return "auth"
+	case strings.Contains(text, "refused"):
+		return "refused"
+	case strings.Contains(text, "reset") || strings.Contains(text, "eof"):
+		return "reset"
+	case strings.Contains(text, "no such host"):
+		return "dns"
+	case strings.Contains(text, "tls") || strings.Contains(text, "certificate"):
+		return "tls"
+	case strings.Contains(text, "код ответа"):
+		return "status " + strings.TrimSpace(text[strings.LastIndex(text, " "):])
+	case strings.Contains(text, "proxy") || strings.Contains(text, "socks") || strings.Contains(text, "прокси"):
+		return "proxy"
+	}
+
+	return "other"
+}
+
+// Печать результатов таблицей
+func PrintResults(results []Result) {
+	fmt.Printf("%-40s %6s %8s %8s %8s %8s %9s  %s\n",
+		"ПРОКСИ", "ЗАПР.", "УСПЕХ", "404", "P50", "P95", "В МИНУТУ", "ОШИБКИ")
+
+	passed := 0
+
+	for _, r := range results {
+		mark := " "
+		if r.IsPassed {
+			mark = "+"
+			passed++
+		}
+
+		fmt.Printf("%s %-38s %6d %7.0f%% %7.0f%% %6dms %6dms %9.0f  %s\n",
+			mark, r.Proxy, r.Requests, r.SuccessRate*100, r.SoftNotFoundRate*100,
+			r.P50Ms, r.P95Ms, r.RatePerMinute, formatErrors(r.Errors))
+	}
+
+	fmt.Printf("\nГодных: %d / %d\n", passed, len(results))
+}
+
+// Печать результатов в JSON
+func PrintResultsJSON(results []Result) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(data))
+
+	return nil
+}
+
+// Сохранение годных прокси в файл (с учетными данными), возвращает их число
+func SaveResults(filename string, results []Result) (int, error) {
+	lines := make([]string, 0, len(results))
+
+	for _, r := range results {
+		if r.IsPassed && r.address != "" {
+			lines = append(lines, r.address)
+		}
+	}
+
+	if len(lines) == 0 {
+		return 0, errors.New("годных прокси нет, файл не перезаписан")
+	}
+
+	_, err := file.Write(filename, strings.Join(lines, "\n")+"\n")
+
+	return len(lines), err
+}
+
+// Ошибки в виде "класс: число" по убыванию числа
+func formatErrors(errs map[string]int) string {
+	classes := make([]string, 0, len(errs))
+	for class := range errs {
+		classes = append(classes, class)
+	}
+
+	sort.Slice(classes, func(i, j int) bool {
+		if errs[classes[i]] != errs[classes[j]] {
+			return errs[classes[i]] > errs[classes[j]]
+		}
+		return classes[i] < classes[j]
+	})
+
+	parts := make([]string, 0, len(classes))
+	for _, class := range classes {
+		parts = append(parts, fmt.Sprintf("%s: %d", class, errs[class]))
+	}
+
+	if len(parts) == 0 {
+		return "-"
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,301 @@
+package proxy
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	QuarantineFailures      = 3                // Число неудач подряд до карантина
+	QuarantineSoftNotFounds = 5                // Число "мягких" 404 подряд при проверках до карантина
+	QuarantineBackoff       = 30 * time.Second // Начальная длительность карантина
+	QuarantineMax           = 10 * time.Minute // Предельная длительность карантина
+	LatencyWeight           = 0.3              // Вес нового замера в скользящей задержке
+	ScoreTolerance          = 0.8              // Доля лучшей оценки, при которой прокси считается равноценным
+)
+
+// Состояние прокси
+type Health struct {
+	Proxy            string        `json:"proxy"`
+	Class            string        `json:"class"`
+	Tokens           float64       `json:"tokens"`
+	Requests         int           `json:"requests"`
+	Successes        int           `json:"successes"`
+	Failures         int           `json:"failures"`
+	SoftNotFounds    int           `json:"softNotFounds"`
+	SuccessRate      float64       `json:"successRate"`
+	SoftNotFoundRate float64       `json:"softNotFoundRate"`
+	Latency          time.Duration `json:"-"`
+	LatencyMs        int64         `json:"latencyMs"`
+	Score            float64       `json:"score"`
+	FailureStreak    int           `json:"failureStreak,omitempty"`
+	SoftStreak       int           `json:"softStreak,omitempty"`
+	Backoff          time.Duration `json:"-"`
+	IsQuarantined    bool          `json:"isQuarantined"`
+	QuarantinedUntil time.Time     `json:"-"`
+	LastError        string        `json:"lastError,omitempty"`
+	LastCheck        time.Time     `json:"lastCheck"`
+}
+
+// Состояния всех прокси
+type health struct {
+	h map[string]*Health
+	m sync.RWMutex
+}
+
+// Объект состояний
+var h = health{h: make(map[string]*Health)}
+
+// Функция проверки прокси (задержка, "мягкая" 404, ошибка)
+type ProbeFunc func(proxy string) (time.Duration, bool, error)
+
+// Учет результата запроса через прокси ("мягкая" 404 учитывается в доле,
+// но к карантину не ведет - она может относиться к самому каналу)
+func Record(proxy string, latency time.Duration, isSoftNotFound bool, err error) {
+	record(proxy, latency, isSoftNotFound, false, err)
+}
+
+// Учет результата проверки прокси (запрос к заведомо существующей странице,
+// поэтому "мягкие" 404 подряд ведут к карантину)
+func RecordProbe(proxy string, latency time.Duration, isSoftNotFound bool, err error) {
+	record(proxy, latency, isSoftNotFound, true, err)
+}
+
+// Учет результата запроса или проверки
+func record(proxy string, latency time.Duration, isSoftNotFound, isProbe bool, err error) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	s, ok := h.h[proxy]
+	if !ok {
+		s = &Health{Proxy: proxy}
+		h.h[proxy] = s
+	}
+
+	now := time.Now()
+
+	s.Requests++
+	s.LastCheck = now
+
+	switch {
+	case err != nil:
+		s.Failures++
+		s.FailureStreak++
+		s.LastError = Redact(err.Error())
+	case isSoftNotFound:
+		s.SoftNotFounds++
+		s.FailureStreak = 0
+		if isProbe {
+			s.SoftStreak++
+		}
+	default:
+		s.Successes++
+		s.FailureStreak = 0
+		s.SoftStreak = 0
+		s.Backoff = 0
+		s.QuarantinedUntil = time.Time{}
+	}
+
+	if err == nil && latency > 0 {
+		if s.Latency == 0 {
+			s.Latency = latency
+		} else {
+			s.Latency = time.Duration(LatencyWeight*float64(latency) + (1-LatencyWeight)*float64(s.Latency))
+		}
+	}
+
+	// Карантин с удвоением длительности при повторных неудачах
+	if proxy != "" && (s.FailureStreak >= QuarantineFailures || s.SoftStreak >= QuarantineSoftNotFounds) {
+		s.Backoff *= 2
+		if s.Backoff < QuarantineBackoff {
+			s.Backoff = QuarantineBackoff
+		}
+		if s.Backoff > QuarantineMax {
+			s.Backoff = QuarantineMax
+		}
+
+		s.QuarantinedUntil = now.Add(s.Backoff)
+	}
+}
+
+// Проверка нахождения прокси в карантине
+func IsQuarantined(proxy string) bool {
+	h.m.RLock()
+	defer h.m.RUnlock()
+
+	s, ok := h.h[proxy]
+	if !ok {
+		return false
+	}
+
+	return time.Now().Before(s.QuarantinedUntil)
+}
+
+// Оценка прокси (доля успехов без "мягких" 404 с учетом задержки)
+func Score(proxy string) float64 {
+	h.m.RLock()
+	defer h.m.RUnlock()
+
+	s, ok := h.h[proxy]
+	if !ok {
+		return score(Health{})
+	}
+
+	return score(*s)
+}
+
+// Расчет оценки (непроверенные прокси оцениваются оптимистично, чтобы попасть в ротацию)
+func score(s Health) float64 {
+	if s.Requests == 0 {
+		return 1
+	}
+
+	successRate := float64(s.Successes+1) / float64(s.Requests+2)
+	softRate := float64(s.SoftNotFounds) / float64(s.Requests+2)
+
+	return successRate * (1 - softRate) / (1 + s.Latency.Seconds())
+}
+
+// Получение состояния прокси (с расчетными полями)
+func State(proxy string) Health {
+	h.m.RLock()
+	defer h.m.RUnlock()
+
+	s := Health{Proxy: proxy}
+	if found, ok := h.h[proxy]; ok {
+		s = *found
+	}
+
+	return view(s)
+}
+
+// Получение состояний всех прокси пула (по убыванию оценки)
+func States() []Health {
+	p.m.Lock()
+	proxies := append([]string{}, p.proxies...)
+	p.m.Unlock()
+
+	states := make([]Health, 0, len(proxies))
+	for _, proxy := range proxies {
+		states = append(states, State(proxy))
+	}
+
+	sort.SliceStable(states, func(i, j int) bool {
+		return states[i].Score > states[j].Score
+	})
+
+	return states
+}
+
+// Подготовка состояния к выводу (расчетные поля, скрытие учетных данных)
+func view(s Health) Health {
+	s.Score = score(s)
+	s.LatencyMs = s.Latency.Milliseconds()
+	s.IsQuarantined = time.Now().Before(s.QuarantinedUntil)
+
+	if s.Requests > 0 {
+		s.SuccessRate = float64(s.Successes) / float64(s.Requests)
+		s.SoftNotFoundRate = float64(s.SoftNotFounds) / float64(s.Requests)
+	}
+
+	s.Class = Class(s.Proxy)
+	s.Tokens = Tokens(s.Proxy)
+
+	s.Proxy = Redact(s.Proxy)
+	if s.Proxy == "" {
+		s.Proxy = "direct"
+	}
+
+	return s
+}
+
+// Сброс состояний
+func ResetHealth() {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	h.h = make(map[string]*Health)
+}
+
+// Проверка всех прокси пула (параллельно)
+func Probe(check ProbeFunc) {
+	p.m.Lock()
+	proxies := append([]string{}, p.proxies[1:]...)
+	p.m.Unlock()
+
+	var wg sync.WaitGroup
+
+	for _, proxy := range proxies {
+		wg.Add(1)
+
+		go func(proxy string) {
+			defer wg.Done()
+
+			latency, isSoftNotFound, err := check(proxy)
+			RecordProbe(proxy, latency, isSoftNotFound, err)
+		}(proxy)
+	}
+
+	wg.Wait()
+}
+
+// Запуск фоновой проверки прокси (возвращает функцию остановки)
+func StartProbing(interval time.Duration, check ProbeFunc) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			Probe(check)
+
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// Выбор следующего прокси после from (здоровые и быстрые в приоритете,
+// равноценные - по кругу, 0 - если все прокси в карантине)
+func (p *proxy) next(from int) int {
+	var (
+		n          = len(p.proxies)
+		candidates = make([]int, 0, n)
+		scores     = make([]float64, 0, n)
+		best       float64
+	)
+
+	for i := 1; i <= n; i++ {
+		idx := (from + i) % n
+		if idx == 0 || IsQuarantined(p.proxies[idx]) {
+			continue
+		}
+
+		s := Score(p.proxies[idx])
+		if s > best {
+			best = s
+		}
+
+		candidates = append(candidates, idx)
+		scores = append(scores, s)
+	}
+
+	for i, idx := range candidates {
+		if scores[i] >= best*ScoreTolerance {
+			return idx
+		}
+	}
+
+	return 0
+}
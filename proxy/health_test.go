@@ -0,0 +1,243 @@
+package proxy
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRecord(t *testing.T) {
+	Clear()
+
+	proxy := "http://164.92.180.67:8080"
+	failure := errors.New("connection refused")
+
+	tests := []struct {
+		test          string
+		latency       time.Duration
+		isSoft        bool
+		err           error
+		successes     int
+		failures      int
+		softNotFounds int
+		isQuarantined bool
+		backoff       time.Duration
+	}{
+		{"Success", 100 * time.Millisecond, false, nil, 1, 0, 0, false, 0},
+		{"Failure1", 0, false, failure, 1, 1, 0, false, 0},
+		{"Failure2", 0, false, failure, 1, 2, 0, false, 0},
+		{"Failure3", 0, false, failure, 1, 3, 0, true, QuarantineBackoff},
+		{"Failure4", 0, false, failure, 1, 4, 0, true, 2 * QuarantineBackoff},
+		{"Soft", 100 * time.Millisecond, true, nil, 1, 4, 1, true, 2 * QuarantineBackoff},
+		{"Release", 100 * time.Millisecond, false, nil, 2, 4, 1, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			Record(proxy, tt.latency, tt.isSoft, tt.err)
+
+			s := h.h[proxy]
+
+			if s.Successes != tt.successes || s.Failures != tt.failures || s.SoftNotFounds != tt.softNotFounds {
+				t.Errorf("Получено значение: %d/%d/%d, ожидается: %d/%d/%d", s.Successes, s.Failures,
+					s.SoftNotFounds, tt.successes, tt.failures, tt.softNotFounds)
+			}
+			if result := IsQuarantined(proxy); result != tt.isQuarantined {
+				t.Errorf("Карантин - получено значение: %v, ожидается: %v", result, tt.isQuarantined)
+			}
+			if s.Backoff != tt.backoff {
+				t.Errorf("Backoff - получено значение: %v, ожидается: %v", s.Backoff, tt.backoff)
+			}
+		})
+	}
+}
+
+func TestRecordSoftNotFounds(t *testing.T) {
+	Clear()
+
+	proxy := "http://164.92.180.67:8080"
+
+	// "Мягкие" 404 обычных запросов (несуществующие каналы) к карантину не ведут
+	for i := 0; i < 2*QuarantineSoftNotFounds; i++ {
+		Record(proxy, 0, true, nil)
+	}
+	if IsQuarantined(proxy) {
+		t.Fatalf("Карантин после \"мягких\" 404 обычных запросов")
+	}
+	if s := State(proxy); s.SoftNotFoundRate != 1 {
+		t.Errorf("Доля \"мягких\" 404 - получено значение: %v, ожидается: %v", s.SoftNotFoundRate, 1)
+	}
+
+	for i := 0; i < QuarantineSoftNotFounds; i++ {
+		if IsQuarantined(proxy) {
+			t.Fatalf("Карантин после %d \"мягких\" 404, ожидается после %d", i, QuarantineSoftNotFounds)
+		}
+		RecordProbe(proxy, 0, true, nil)
+	}
+
+	if !IsQuarantined(proxy) {
+		t.Errorf("Прокси не в карантине после %d \"мягких\" 404", QuarantineSoftNotFounds)
+	}
+
+	// Прямое соединение в карантин не попадает
+	for i := 0; i < QuarantineFailures; i++ {
+		Record("", 0, false, errors.New("timeout"))
+	}
+	if IsQuarantined("") {
+		t.Errorf("Прямое соединение в карантине")
+	}
+}
+
+func TestScore(t *testing.T) {
+	Clear()
+
+	Record("fast", 100*time.Millisecond, false, nil)
+	Record("slow", 3*time.Second, false, nil)
+	Record("soft", 100*time.Millisecond, true, nil)
+	Record("failed", 0, false, errors.New("timeout"))
+
+	tests := []struct {
+		test   string
+		better string
+		worse  string
+	}{
+		{"FastSlow", "fast", "slow"},
+		{"FastSoft", "fast", "soft"},
+		{"FastFailed", "fast", "failed"},
+		{"UntestedFast", "untested", "fast"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			better, worse := Score(tt.better), Score(tt.worse)
+
+			if better <= worse {
+				t.Errorf("Оценка %q (%v) не выше оценки %q (%v)", tt.better, better, tt.worse, worse)
+			}
+		})
+	}
+}
+
+func TestNextHealthy(t *testing.T) {
+	Enable(mainThreshold, proxyThreshold, cooldown)
+
+	tests := []struct {
+		test    string
+		prepare func(proxies []string)
+		from    int
+		result  int
+	}{
+		{"RoundRobin", func(proxies []string) {}, 1, 2},
+		{"SkipQuarantined", func(proxies []string) {
+			for i := 0; i < QuarantineFailures; i++ {
+				Record(proxies[2], 0, false, errors.New("timeout"))
+			}
+		}, 1, 3},
+		{"PreferFast", func(proxies []string) {
+			Record(proxies[1], 3*time.Second, false, nil)
+			Record(proxies[2], 3*time.Second, false, nil)
+			Record(proxies[3], 100*time.Millisecond, false, nil)
+		}, 1, 3},
+		{"AllQuarantined", func(proxies []string) {
+			for _, proxy := range proxies[1:] {
+				for i := 0; i < QuarantineFailures; i++ {
+					Record(proxy, 0, false, errors.New("timeout"))
+				}
+			}
+		}, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			Clear()
+			PrepareFromList(validProxies)
+			tt.prepare(p.proxies)
+
+			result := p.next(tt.from)
+
+			if result != tt.result {
+				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
+			}
+		})
+	}
+}
+
+func TestCooldownQuarantined(t *testing.T) {
+	Enable(mainThreshold, proxyThreshold, cooldown)
+	Clear()
+	PrepareFromList(validProxies)
+	p.lastCooldown = time.Now()
+
+	// Переход на первый прокси
+	var result string
+	for i := 0; i <= int(mainThreshold); i++ {
+		result = Cooldown()
+	}
+	if result != p.proxies[1] {
+		t.Fatalf("Получено значение: %q, ожидается: %q", result, p.proxies[1])
+	}
+
+	// Прокси умер - следующий запрос уходит на другой
+	for i := 0; i < QuarantineFailures; i++ {
+		Record(p.proxies[1], 0, false, errors.New("timeout"))
+	}
+
+	result = Cooldown()
+	if result != p.proxies[2] {
+		t.Errorf("Получено значение: %q, ожидается: %q", result, p.proxies[2])
+	}
+}
+
+func TestProbe(t *testing.T) {
+	Enable(mainThreshold, proxyThreshold, cooldown)
+	Clear()
+	PrepareFromList(socksProxies)
+
+	var calls int64
+	check := func(proxy string) (time.Duration, bool, error) {
+		atomic.AddInt64(&calls, 1)
+
+		if strings.HasPrefix(proxy, "socks5h") {
+			return 0, false, errors.New("dial tcp: user:pass@ refused")
+		}
+		return 50 * time.Millisecond, false, nil
+	}
+
+	Probe(check)
+
+	if n := atomic.LoadInt64(&calls); n != 3 {
+		t.Fatalf("Проверено прокси: %v, ожидается: %v", n, 3)
+	}
+
+	for _, s := range States() {
+		switch {
+		case s.Proxy == "direct":
+			if s.Requests != 0 {
+				t.Errorf("Прямое соединение проверено: %v", s.Requests)
+			}
+		case strings.HasPrefix(s.Proxy, "socks5h"):
+			if s.Failures != 1 || s.SuccessRate != 0 {
+				t.Errorf("Получено значение: %+v, ожидается неудача", s)
+			}
+		default:
+			if s.Successes != 1 || s.LatencyMs != 50 {
+				t.Errorf("Получено значение: %+v, ожидается успех", s)
+			}
+		}
+		if strings.Contains(s.Proxy, "pass") || strings.Contains(s.LastError, "pass") {
+			t.Errorf("Учетные данные не скрыты: %+v", s)
+		}
+	}
+
+	// Фоновая проверка
+	stop := StartProbing(10*time.Millisecond, check)
+	time.Sleep(35 * time.Millisecond)
+	stop()
+	stop()
+
+	if n := atomic.LoadInt64(&calls); n < 9 {
+		t.Errorf("Проверено прокси: %v, ожидается не менее: %v", n, 9)
+	}
+}
@@ -0,0 +1,322 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Политика при исчерпании лимитов всех прокси
+type Policy int
+
+const (
+	PolicyWait     Policy = iota // Ожидание освобождения лимита (не дольше maxWait)
+	PolicyFailFast               // Немедленная ошибка
+)
+
+// Классы прокси по умолчанию
+const (
+	ClassDirect = "direct" // Прямое соединение
+	ClassProxy  = "proxy"  // Любой прокси, для которого не задан класс по схеме
+)
+
+// Политики по названию
+var Policies = map[string]Policy{
+	"wait":      PolicyWait,
+	"fail-fast": PolicyFailFast,
+}
+
+// Лимиты по умолчанию (Телеграм допускает около 300 запросов в минуту с одного адреса)
+const DefaultRates = "direct=250/20,proxy=250/20"
+
+// Ошибка исчерпания лимитов
+var ErrExhausted = errors.New("лимиты запросов всех прокси исчерпаны")
+
+// Лимит запросов (в минуту, с допустимым всплеском)
+type Rate struct {
+	PerMinute int `json:"perMinute"`
+	Burst     int `json:"burst"`
+}
+
+// Токен-бакет исходящего адреса
+type bucket struct {
+	rate   Rate
+	tokens float64
+	last   time.Time
+}
+
+// Ограничитель запросов
+type limiter struct {
+	isEnabled bool
+	rates     map[string]Rate
+	buckets   map[string]*bucket
+	policy    Policy
+	maxWait   time.Duration
+	m         sync.Mutex
+}
+
+// Объект ограничителя
+var l = limiter{
+	rates:   make(map[string]Rate),
+	buckets: make(map[string]*bucket),
+	maxWait: 30 * time.Second,
+}
+
+// Задание лимита для класса прокси (direct, proxy или схема: http, socks5...),
+// нулевой лимит запрещает запросы через этот класс
+func SetRate(class string, perMinute, burst int) {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	l.isEnabled = true
+	l.rates[strings.ToLower(class)] = Rate{PerMinute: perMinute, Burst: burst}
+
+	// Лимиты меняются - бакеты создаются заново
+	l.buckets = make(map[string]*bucket)
+}
+
+// Разбор лимитов классов из строки вида "direct=250/20,proxy=250/20,socks5=100"
+// (всплеск можно не указывать - тогда он равен 1)
+func ParseRates(value string) (map[string]Rate, error) {
+	rates := make(map[string]Rate)
+
+	for _, item := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+		class, rate, ok := strings.Cut(item, "=")
+		if !ok || class == "" {
+			return nil, fmt.Errorf("неверный лимит %q (ожидается класс=в минуту/всплеск)", item)
+		}
+
+		perMinute, burst, hasBurst := strings.Cut(rate, "/")
+
+		r := Rate{Burst: 1}
+		var err error
+
+		if r.PerMinute, err = strconv.Atoi(perMinute); err != nil || r.PerMinute < 0 {
+			return nil, fmt.Errorf("неверный лимит %q (число в минуту)", item)
+		}
+		if hasBurst {
+			if r.Burst, err = strconv.Atoi(burst); err != nil || r.Burst < 1 {
+				return nil, fmt.Errorf("неверный лимит %q (всплеск)", item)
+			}
+		}
+
+		rates[strings.ToLower(class)] = r
+	}
+
+	return rates, nil
+}
+
+// Получение политики по названию
+func ParsePolicy(name string) (Policy, error) {
+	policy, ok := Policies[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return PolicyWait, fmt.Errorf("политика %q не поддерживается", name)
+	}
+
+	return policy, nil
+}
+
+// Задание политики при исчерпании лимитов
+func SetPolicy(policy Policy, maxWait time.Duration) {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.policy = policy
+	l.maxWait = maxWait
+}
+
+// Отключение лимитов
+func DisableLimits() {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.isEnabled = false
+	l.rates = make(map[string]Rate)
+	l.buckets = make(map[string]*bucket)
+}
+
+// Определение класса прокси
+func Class(proxy string) string {
+	if proxy == "" {
+		return ClassDirect
+	}
+
+	if scheme, _, ok := strings.Cut(proxy, "://"); ok {
+		return strings.ToLower(scheme)
+	}
+
+	return ClassProxy
+}
+
+// Получение прокси текущей стратегией с учетом лимитов (ожидание или ошибка при исчерпании)
+func Take(key string) (string, error) {
+	proxy := Select(key)
+
+	l.m.Lock()
+	isEnabled, policy, maxWait := l.isEnabled, l.policy, l.maxWait
+	l.m.Unlock()
+
+	if !isEnabled {
+		return proxy, nil
+	}
+
+	// Стратегия может закреплять прокси за ключом - тогда замена прокси недопустима
+	_, isPinned := CurrentStrategy().(interface{ pinned() })
+
+	deadline := time.Now().Add(maxWait)
+
+	for {
+		ok, wait := take(proxy)
+		if ok {
+			return proxy, nil
+		}
+
+		// Любой другой прокси с доступным лимитом (или ожидание закрепленного,
+		// который выбирается заново - вдруг он ушел в карантин)
+		if isPinned {
+			proxy = Select(key)
+		} else {
+			other, ok, w := takeAny()
+			if ok {
+				return other, nil
+			}
+			wait = w
+		}
+
+		if policy == PolicyFailFast || time.Now().Add(wait).After(deadline) {
+			return "", ErrExhausted
+		}
+
+		time.Sleep(wait)
+	}
+}
+
+// Попытка взять токен для прокси (иначе - время до появления токена)
+func take(proxy string) (bool, time.Duration) {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	b, ok := l.buckets[proxy]
+	if !ok {
+		rate, ok := l.rate(proxy)
+		if !ok {
+			return true, 0
+		}
+
+		b = &bucket{rate: rate, last: time.Now()}
+		if rate.PerMinute > 0 {
+			b.tokens = float64(rate.Burst)
+		}
+		l.buckets[proxy] = b
+	}
+
+	return b.take(time.Now())
+}
+
+// Попытка взять токен у любого доступного прокси
+func takeAny() (string, bool, time.Duration) {
+	var (
+		wait    time.Duration
+		proxies = []string{""}
+	)
+
+	p.m.Lock()
+	if p.isEnabled {
+		proxies = append([]string{}, p.proxies...)
+	}
+	p.m.Unlock()
+
+	for _, proxy := range proxies {
+		if IsQuarantined(proxy) {
+			continue
+		}
+
+		ok, w := take(proxy)
+		if ok {
+			return proxy, true, 0
+		}
+
+		if wait == 0 || w < wait {
+			wait = w
+		}
+	}
+
+	if wait == 0 {
+		wait = time.Second
+	}
+
+	return "", false, wait
+}
+
+// Лимит для прокси (по схеме, затем по общему классу; нулевой лимит запрещает запросы)
+func (l *limiter) rate(proxy string) (Rate, bool) {
+	class := Class(proxy)
+
+	if rate, ok := l.rates[class]; ok {
+		return rate, true
+	}
+
+	if class != ClassDirect {
+		if rate, ok := l.rates[ClassProxy]; ok {
+			return rate, true
+		}
+	}
+
+	return Rate{}, false
+}
+
+// Взятие токена (с пополнением за прошедшее время)
+func (b *bucket) take(now time.Time) (bool, time.Duration) {
+	if b.rate.PerMinute <= 0 {
+		return false, time.Hour
+	}
+
+	perSecond := float64(b.rate.PerMinute) / 60
+
+	b.tokens += now.Sub(b.last).Seconds() * perSecond
+	if b.tokens > float64(b.rate.Burst) {
+		b.tokens = float64(b.rate.Burst)
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / perSecond * float64(time.Second))
+
+	return false, wait
+}
+
+// Число доступных запросов прокси (без взятия токена)
+func Tokens(proxy string) float64 {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	b, ok := l.buckets[proxy]
+	if !ok {
+		rate, ok := l.rate(proxy)
+		switch {
+		case !ok:
+			return -1
+		case rate.PerMinute <= 0:
+			return 0
+		}
+		return float64(rate.Burst)
+	}
+
+	tokens := b.tokens + time.Since(b.last).Seconds()*float64(b.rate.PerMinute)/60
+	if tokens > float64(b.rate.Burst) {
+		tokens = float64(b.rate.Burst)
+	}
+
+	return tokens
+}
@@ -1,6 +1,8 @@
 package proxy
 
 import (
+	"errors"
+	"fmt"
 	"net/url"
 	"strings"
 	"sync"
@@ -28,6 +30,9 @@ var p = proxy{proxies: []string{""}}
 
 // Включение прокси
 func Enable(mainThreshold, proxyThreshold uint, cooldown time.Duration) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
 	p.isEnabled = true
 
 	p.mainThreshold = int(mainThreshold)
@@ -39,37 +44,168 @@ func Enable(mainThreshold, proxyThreshold uint, cooldown time.Duration) {
 
 // Выключение прокси
 func Disable() {
+	p.m.Lock()
+	defer p.m.Unlock()
+
 	p.isEnabled = false
 }
 
 // Добавление прокси
 func Prepare(list []string) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	proxies := normalize(list, p.proxies)
+	if len(proxies) == 0 {
+		return
+	}
+
+	// Новый срез, чтобы не менять копии, полученные до добавления
+	p.proxies = append(append(make([]string, 0, len(p.proxies)+len(proxies)), p.proxies...), proxies...)
+}
+
+// Замена всех прокси (атомарно, текущий прокси сохраняется, если остался в списке),
+// возвращает число прокси
+func Replace(list []string) int {
+	proxies := append([]string{""}, normalize(list, nil)...)
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	current, last := p.proxies[p.current], p.proxies[p.last]
+
+	p.proxies = proxies
+
+	p.current = index(p.proxies, current)
+	p.last = index(p.proxies, last)
+	if p.current == 0 && current != "" {
+		p.requests = 0
+	}
+
+	return len(p.proxies) - 1
+}
+
+// Получение списка прокси (без прямого соединения)
+func List() []string {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	return append([]string{}, p.proxies[1:]...)
+}
+
+// Приведение списка прокси к единому виду (без невалидных и уже существующих)
+func normalize(list, existing []string) []string {
 	proxies := make([]string, 0, len(list))
+	existed := map[string]bool{"": true}
 
-	for _, proxy := range list {
-		proxy = strings.TrimSpace(proxy)
+	for _, proxy := range existing {
+		existed[proxy] = true
+	}
 
-		if proxy == "" {
+	for _, proxy := range list {
+		proxy, err := Normalize(proxy)
+		if err != nil || existed[proxy] {
 			continue
 		}
 
-		if !strings.HasPrefix(proxy, "http://") && !strings.HasPrefix(proxy, "https://") {
-			proxy = "http://" + proxy
+		existed[proxy] = true
+		proxies = append(proxies, proxy)
+	}
+
+	return proxies
+}
+
+// Индекс прокси в списке (0 - если его нет)
+func index(list []string, proxy string) int {
+	for i, item := range list {
+		if item == proxy {
+			return i
 		}
+	}
 
-		_, err := url.ParseRequestURI(proxy)
-		if err != nil {
-			continue
+	return 0
+}
+
+// Поддерживаемые схемы прокси
+var Schemes = []string{"http", "https", "socks5", "socks5h"}
+
+// Приведение адреса прокси к виду scheme://[user:password@]host:port
+func Normalize(proxy string) (string, error) {
+	proxy = strings.TrimSpace(proxy)
+
+	if proxy == "" {
+		return "", errors.New("адрес прокси пуст")
+	}
+
+	if !strings.Contains(proxy, "://") {
+		// Формат host:port:user:password
+		if parts := strings.Split(proxy, ":"); len(parts) == 4 && !strings.Contains(proxy, "@") {
+			proxy = parts[2] + ":" + parts[3] + "@" + parts[0] + ":" + parts[1]
 		}
 
-		proxies = append(proxies, proxy)
+		proxy = "http://" + proxy
 	}
 
-	if len(proxies) == 0 {
-		return
+	u, err := url.Parse(proxy)
+	if err != nil || u.Host == "" || strings.ContainsAny(u.Host, " ,") {
+		return "", fmt.Errorf("адрес прокси %q не валиден", Redact(proxy))
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+
+	if !isScheme(u.Scheme) {
+		return "", fmt.Errorf("схема прокси %q не поддерживается", u.Scheme)
+	}
+
+	return u.String(), nil
+}
+
+// Скрытие учетных данных прокси (для логов и вывода)
+func Redact(proxy string) string {
+	scheme, rest, ok := strings.Cut(proxy, "://")
+	if !ok {
+		scheme, rest = "", proxy
+	}
+
+	at := strings.LastIndex(rest, "@")
+	if at == -1 {
+		return proxy
+	}
+
+	rest = "***@" + rest[at+1:]
+
+	if scheme != "" {
+		return scheme + "://" + rest
+	}
+
+	return rest
+}
+
+// Получение учетных данных прокси (адрес без них, логин и пароль)
+func Credentials(proxy string) (address, user, password string) {
+	u, err := url.Parse(proxy)
+	if err != nil || u.User == nil {
+		return proxy, "", ""
+	}
+
+	user = u.User.Username()
+	password, _ = u.User.Password()
+
+	u.User = nil
+	address = u.String()
+
+	return
+}
+
+// Проверка поддержки схемы прокси
+func isScheme(scheme string) bool {
+	for _, s := range Schemes {
+		if scheme == s {
+			return true
+		}
 	}
 
-	p.proxies = append(p.proxies, proxies...)
+	return false
 }
 
 // Добавление прокси из списка
@@ -85,6 +221,14 @@ func PrepareFromFile(filename string) {
 
 // Сброс прокси
 func Reset() {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	p.reset()
+}
+
+// Сброс позиции обхода
+func (p *proxy) reset() {
 	p.current = 0
 	p.last = 0
 	p.requests = 0
@@ -94,8 +238,13 @@ func Reset() {
 
 // Очистка прокси
 func Clear() {
+	p.m.Lock()
 	p.proxies = []string{""}
-	Reset()
+	p.reset()
+	p.m.Unlock()
+
+	ResetHealth()
+	resetStrategy()
 }
 
 // Получение адреса текущего прокси
@@ -128,7 +277,7 @@ func Next() string {
 	proxy = p.proxies[p.current]
 
 	p.last = p.current
-	p.current = (p.current + 1) % len(p.proxies)
+	p.current = p.round(p.current)
 	p.requests = 0
 
 	return proxy
@@ -148,7 +297,7 @@ func Round() string {
 	p.requests++
 
 	if p.current == 0 && p.requests > p.mainThreshold || p.current > 0 && p.requests > p.proxyThreshold {
-		p.current = (p.current + 1) % len(p.proxies)
+		p.current = p.round(p.current)
 		p.requests = 1
 	}
 
@@ -170,18 +319,21 @@ func Cooldown() string {
 
 	p.requests++
 
+	// Уход с прокси, попавшего в карантин
+	if p.current > 0 && IsQuarantined(p.proxies[p.current]) {
+		p.current = p.next(p.current)
+		p.requests = 1
+	}
+
 	if p.current == 0 && p.requests > p.mainThreshold || p.current > 0 && p.requests > p.proxyThreshold {
 		p.requests = 1
 
-		if p.last == 0 {
-			p.current = (p.current + 1) % len(p.proxies)
-			if p.current == 0 {
-				p.current = (p.current + 1) % len(p.proxies)
-			}
+		if p.last == 0 || IsQuarantined(p.proxies[p.last]) {
+			p.current = p.next(p.current)
 		} else {
 			p.current = p.last
-			p.last = 0
 		}
+		p.last = 0
 
 		if time.Now().After(p.lastCooldown.Add(p.cooldown)) {
 			p.lastCooldown = time.Now()
@@ -195,3 +347,17 @@ func Cooldown() string {
 
 	return proxy
 }
+
+// Следующий по кругу прокси (без находящихся в карантине)
+func (p *proxy) round(from int) int {
+	n := len(p.proxies)
+
+	for i := 1; i <= n; i++ {
+		idx := (from + i) % n
+		if idx == 0 || !IsQuarantined(p.proxies[idx]) {
+			return idx
+		}
+	}
+
+	return 0
+}
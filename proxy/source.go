@@ -0,0 +1,253 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Источник списка прокси
+type Source interface {
+	// Название источника (для сообщений об ошибках)
+	Name() string
+
+	// Загрузка списка прокси
+	Load() ([]string, error)
+}
+
+// Файл со списком прокси (по одному в строке)
+type FileSource struct {
+	Filename string
+}
+
+// Переменная окружения со списком прокси (через запятую, пробел или с новой строки)
+type EnvSource struct {
+	Variable string
+}
+
+// Источник, изменение которого проверяется без загрузки (по смене версии)
+type VersionedSource interface {
+	Source
+	Version() string
+}
+
+// Последние загруженные списки источников (по названию)
+type loaded struct {
+	lists map[string][]string
+	m     sync.Mutex
+}
+
+// Объект последних загруженных списков
+var ld = loaded{lists: make(map[string][]string)}
+
+// Url-адрес, возвращающий список прокси (по одному в строке)
+type URLSource struct {
+	Address string
+	Timeout time.Duration
+}
+
+// Предельный размер списка прокси по url-адресу
+var URLSourceMaxSize int64 = 1 << 20
+
+// Создание источника-файла
+func NewFileSource(filename string) *FileSource {
+	return &FileSource{Filename: filename}
+}
+
+// Создание источника-переменной окружения
+func NewEnvSource(variable string) *EnvSource {
+	return &EnvSource{Variable: variable}
+}
+
+// Создание источника-url-адреса
+func NewURLSource(address string) *URLSource {
+	return &URLSource{Address: address, Timeout: 10 * time.Second}
+}
+
+func (s *FileSource) Name() string {
+	return "файл " + s.Filename
+}
+
+// Отсутствующий файл - пустой список
+func (s *FileSource) Load() ([]string, error) {
+	data, err := os.ReadFile(s.Filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseList(string(data)), nil
+}
+
+// Версия файла - время изменения и размер (пустая - файла нет)
+func (s *FileSource) Version() string {
+	info, err := os.Stat(s.Filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ""
+	}
+	if err != nil {
+		return err.Error()
+	}
+
+	return fmt.Sprint(info.ModTime().UnixNano(), info.Size())
+}
+
+func (s *EnvSource) Name() string {
+	return "переменная окружения " + s.Variable
+}
+
+func (s *EnvSource) Load() ([]string, error) {
+	return ParseList(os.Getenv(s.Variable)), nil
+}
+
+// Версия переменной окружения - ее значение
+func (s *EnvSource) Version() string {
+	return os.Getenv(s.Variable)
+}
+
+func (s *URLSource) Name() string {
+	return "url-адрес " + Redact(s.Address)
+}
+
+func (s *URLSource) Load() ([]string, error) {
+	if s.Address == "" {
+		return nil, errors.New("url-адрес пуст")
+	}
+
+	client := &http.Client{Timeout: s.Timeout}
+
+	resp, err := client.Get(s.Address)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("код ответа %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, URLSourceMaxSize))
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseList(string(data)), nil
+}
+
+// Разбор списка прокси (разделители - новая строка, запятая, точка с запятой и пробелы,
+// комментарии начинаются с #)
+func ParseList(text string) []string {
+	list := make([]string, 0)
+
+	for _, line := range strings.Split(text, "\n") {
+		if i := strings.Index(line, "#"); i != -1 {
+			line = line[:i]
+		}
+
+		list = append(list, strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\r'
+		})...)
+	}
+
+	return list
+}
+
+// Загрузка прокси из источников с заменой текущих (для источника с ошибкой берется его прошлый
+// список, ошибки объединяются), возвращает число прокси. Если ни один источник не загрузился
+// и прошлых списков нет, текущие прокси не заменяются
+func Load(sources ...Source) (int, error) {
+	var (
+		list    = make([]string, 0)
+		errs    []error
+		isKnown bool // Есть загруженный или прошлый список
+	)
+
+	ld.m.Lock()
+
+	for _, source := range sources {
+		proxies, err := source.Load()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("источник прокси (%s): %w", source.Name(), err))
+
+			var ok bool
+			if proxies, ok = ld.lists[source.Name()]; ok {
+				isKnown = true
+			}
+		} else {
+			ld.lists[source.Name()] = proxies
+			isKnown = true
+		}
+
+		list = append(list, proxies...)
+	}
+
+	ld.m.Unlock()
+
+	if !isKnown && len(errs) > 0 {
+		return len(List()), errors.Join(errs...)
+	}
+
+	return Replace(list), errors.Join(errs...)
+}
+
+// Периодическая проверка источников с перезагрузкой прокси после изменений (источники без
+// версии, например url-адреса, загружаются каждый раз), возвращает функцию остановки,
+// ошибки передаются в onError
+func Watch(interval time.Duration, onError func(error), sources ...Source) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+	versions := versionsOf(sources)
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			current := versionsOf(sources)
+			if current != nil && versions != nil && reflect.DeepEqual(current, versions) {
+				continue
+			}
+			versions = current
+
+			if _, err := Load(sources...); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}()
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// Версии источников (nil - есть источник без версии)
+func versionsOf(sources []Source) []string {
+	versions := make([]string, 0, len(sources))
+
+	for _, source := range sources {
+		v, ok := source.(VersionedSource)
+		if !ok {
+			return nil
+		}
+
+		versions = append(versions, v.Version())
+	}
+
+	return versions
+}
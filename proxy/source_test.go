@@ -0,0 +1,310 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseList(t *testing.T) {
+	tests := []struct {
+		test   string
+		value  string
+		result []string
+	}{
+		{"Lines", "http://164.92.180.67:8080\n186.232.119.58:3128\r\n", []string{"http://164.92.180.67:8080", "186.232.119.58:3128"}},
+		{"Separators", "http://164.92.180.67:8080, 186.232.119.58:3128;socks5://164.92.180.67:1080",
+			[]string{"http://164.92.180.67:8080", "186.232.119.58:3128", "socks5://164.92.180.67:1080"}},
+		{"Comments", "# Список прокси\n186.232.119.58:3128 # резервный\n\n", []string{"186.232.119.58:3128"}},
+		{"Empty", "", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			result := ParseList(tt.value)
+
+			if !reflect.DeepEqual(result, tt.result) {
+				t.Errorf("Получено значение: %q, ожидается: %q", result, tt.result)
+			}
+		})
+	}
+}
+
+func TestSources(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "proxies")
+	os.WriteFile(filename, []byte("http://164.92.180.67:8080\n186.232.119.58:3128\n"), 0644)
+
+	t.Setenv("PARSER_TEST_PROXIES", "socks5://164.92.180.67:1080,186.232.119.58:3128")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/proxies" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "https://105.112.191.250:3128\n")
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		test   string
+		source Source
+		result []string
+		isErr  bool
+	}{
+		{"File", NewFileSource(filename), []string{"http://164.92.180.67:8080", "186.232.119.58:3128"}, false},
+		{"FileNotExists", NewFileSource(filename + "_"), []string{}, false},
+		{"Env", NewEnvSource("PARSER_TEST_PROXIES"), []string{"socks5://164.92.180.67:1080", "186.232.119.58:3128"}, false},
+		{"EnvEmpty", NewEnvSource("PARSER_TEST_PROXIES_EMPTY"), []string{}, false},
+		{"URL", NewURLSource(server.URL + "/proxies"), []string{"https://105.112.191.250:3128"}, false},
+		{"URLNotFound", NewURLSource(server.URL + "/other"), nil, true},
+		{"URLEmpty", NewURLSource(""), nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			result, err := tt.source.Load()
+
+			if (err != nil) != tt.isErr {
+				t.Fatalf("Получена ошибка: %v", err)
+			}
+			if !tt.isErr && !reflect.DeepEqual(result, tt.result) {
+				t.Errorf("Получено значение: %q, ожидается: %q", result, tt.result)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	defer Clear()
+
+	filename := filepath.Join(t.TempDir(), "proxies")
+	os.WriteFile(filename, []byte("http://164.92.180.67:8080\n186.232.119.58:3128\n"), 0644)
+
+	t.Setenv("PARSER_TEST_PROXIES", "HTTP://164.92.180.67:8080 socks5://164.92.180.67:1080")
+
+	Clear()
+	PrepareFromList(validProxies)
+
+	// Ни один источник не загрузился, прошлых списков нет - прокси не заменяются
+	if n, err := Load(NewURLSource(""), NewURLSource("http://127.0.0.1:0")); err == nil || n != len(validProxies) {
+		t.Fatalf("Получено значение: %d %q (%v), ожидается: %d прокси и ошибка", n, List(), err, len(validProxies))
+	}
+
+	Clear()
+
+	n, err := Load(NewFileSource(filename), NewEnvSource("PARSER_TEST_PROXIES"))
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	result := []string{"http://164.92.180.67:8080", "http://186.232.119.58:3128", "socks5://164.92.180.67:1080"}
+
+	if n != len(result) || !reflect.DeepEqual(List(), result) {
+		t.Fatalf("Получено значение: %d %q, ожидается: %q", n, List(), result)
+	}
+
+	// Отсутствующий файл - пустой список, прокси из других источников загружаются
+	if n, err := Load(NewFileSource(filename+"_"), NewEnvSource("PARSER_TEST_PROXIES")); err != nil || n != 2 {
+		t.Errorf("Получено значение: %d %q (%v), ожидается 2 прокси", n, List(), err)
+	}
+
+	// Ошибка источника - его прошлый список остается, остальные загружаются
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, "https://105.112.191.250:3128\n")
+	}))
+	defer server.Close()
+
+	url := NewURLSource(server.URL)
+
+	if n, err := Load(url, NewEnvSource("PARSER_TEST_PROXIES")); err != nil || n != 3 {
+		t.Fatalf("Получено значение: %d %q (%v), ожидается 3 прокси", n, List(), err)
+	}
+
+	status = http.StatusInternalServerError
+	t.Setenv("PARSER_TEST_PROXIES", "socks5://164.92.180.67:1080")
+
+	n, err = Load(url, NewEnvSource("PARSER_TEST_PROXIES"), NewURLSource(""))
+
+	result = []string{"https://105.112.191.250:3128", "socks5://164.92.180.67:1080"}
+
+	if err == nil || !strings.Contains(err.Error(), "код ответа 500") || !strings.Contains(err.Error(), "url-адрес пуст") {
+		t.Errorf("Получена ошибка: %v, ожидаются ошибки обоих url-адресов", err)
+	}
+	if n != len(result) || !reflect.DeepEqual(List(), result) {
+		t.Errorf("Получено значение: %q, ожидается: %q", List(), result)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	defer Disable()
+	defer Clear()
+
+	Clear()
+	PrepareFromList(validProxies)
+	Enable(mainThreshold, proxyThreshold, cooldown)
+
+	tests := []struct {
+		test    string
+		current int
+		list    []string
+		result  string
+	}{
+		{"Kept", 2, []string{"186.232.119.58:3128", "https://105.112.191.250:3128"}, "https://105.112.191.250:3128"},
+		{"Removed", 1, []string{"socks5://164.92.180.67:1080"}, ""},
+		{"Direct", 0, validProxies, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			p.m.Lock()
+			p.current = tt.current
+			p.m.Unlock()
+
+			if n := Replace(tt.list); n != len(tt.list) {
+				t.Errorf("Получено прокси: %v, ожидается: %v", n, len(tt.list))
+			}
+			if result := Current(); result != tt.result {
+				t.Errorf("Получено значение: %q, ожидается: %q", result, tt.result)
+			}
+		})
+	}
+}
+
+// Источник с подсчетом загрузок
+type countingSource struct {
+	FileSource
+	loads atomic.Int32
+}
+
+func (s *countingSource) Load() ([]string, error) {
+	s.loads.Add(1)
+	return s.FileSource.Load()
+}
+
+func TestWatch(t *testing.T) {
+	defer Clear()
+
+	filename := filepath.Join(t.TempDir(), "proxies")
+	os.WriteFile(filename, []byte("http://164.92.180.67:8080\n"), 0644)
+
+	source := &countingSource{FileSource: FileSource{Filename: filename}}
+
+	Clear()
+	Load(source)
+
+	var (
+		errs []error
+		m    sync.Mutex
+	)
+
+	stop := Watch(5*time.Millisecond, func(err error) {
+		m.Lock()
+		defer m.Unlock()
+		errs = append(errs, err)
+	}, source)
+	defer stop()
+
+	// Неизмененный файл не перезагружается
+	time.Sleep(50 * time.Millisecond)
+
+	if loads := source.loads.Load(); loads != 1 {
+		t.Errorf("Получено загрузок: %v, ожидается: %v", loads, 1)
+	}
+
+	// Изменение файла подхватывается
+	os.WriteFile(filename, []byte("http://164.92.180.67:8080\nhttps://105.112.191.250:3128\n"), 0644)
+
+	if !waitFor(func() bool { return len(List()) == 2 }) {
+		t.Fatalf("Получено значение: %q, ожидается 2 прокси", List())
+	}
+
+	// Файл удален - прокси больше нет, ошибки нет
+	os.Remove(filename)
+
+	if !waitFor(func() bool { return len(List()) == 0 }) {
+		t.Fatalf("Получено значение: %q, ожидается 0 прокси", List())
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if len(errs) > 0 {
+		t.Errorf("Получены ошибки: %v", errs)
+	}
+}
+
+func TestReplaceConcurrent(t *testing.T) {
+	defer UseStrategy(&cooldownStrategy{})
+	defer DisableLimits()
+	defer Disable()
+	defer Clear()
+
+	Clear()
+	PrepareFromList(validProxies)
+	Enable(0, 1, time.Hour)
+	SetRate(ClassProxy, 6000, 100)
+
+	var (
+		wg   sync.WaitGroup
+		done = make(chan struct{})
+	)
+
+	for _, strategy := range []string{StrategyCooldown, StrategyRoundRobin, StrategySticky} {
+		SetStrategy(strategy)
+
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+
+			go func(i int) {
+				defer wg.Done()
+
+				for {
+					select {
+					case <-done:
+						return
+					default:
+					}
+
+					if _, err := Take(fmt.Sprint(i)); err != nil && !errors.Is(err, ErrExhausted) {
+						t.Errorf("Получена ошибка: %v", err)
+					}
+					Record(Current(), time.Millisecond, false, nil)
+					States()
+				}
+			}(i)
+		}
+
+		for i := 0; i < 50; i++ {
+			if i%2 == 0 {
+				Replace(validProxies[:1])
+			} else {
+				Replace(socksProxies)
+			}
+		}
+	}
+
+	close(done)
+	wg.Wait()
+}
+
+// Ожидание выполнения условия (не дольше секунды)
+func waitFor(condition func() bool) bool {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if condition() {
+			return true
+		}
+	}
+
+	return false
+}
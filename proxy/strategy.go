@@ -0,0 +1,321 @@
+package proxy
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Стратегия выбора прокси
+type Strategy interface {
+	// Название стратегии
+	Name() string
+
+	// Выбор прокси для запроса (key - ключ запроса, например имя канала)
+	Select(key string) string
+}
+
+// Встроенные стратегии
+const (
+	StrategyCooldown    = "cooldown"     // По кругу с порогами и возвратом на прямое соединение (по умолчанию)
+	StrategyRoundRobin  = "round-robin"  // По кругу на каждый запрос
+	StrategyLRU         = "lru"          // Давно не использованный
+	StrategyWeighted    = "weighted"     // Случайный с весом по оценке
+	StrategySticky      = "sticky"       // Закрепленный за каналом
+	StrategyDirectFirst = "direct-first" // Прямое соединение, при исчерпании лимита - прокси
+)
+
+// Конструкторы стратегий по названию
+var Strategies = map[string]func() Strategy{
+	StrategyCooldown:    func() Strategy { return &cooldownStrategy{} },
+	StrategyRoundRobin:  func() Strategy { return &roundRobinStrategy{} },
+	StrategyLRU:         func() Strategy { return &lruStrategy{used: make(map[string]time.Time)} },
+	StrategyWeighted:    func() Strategy { return &weightedStrategy{} },
+	StrategySticky:      func() Strategy { return &stickyStrategy{} },
+	StrategyDirectFirst: func() Strategy { return &directFirstStrategy{} },
+}
+
+// Текущая стратегия
+type selector struct {
+	strategy Strategy
+	m        sync.RWMutex
+}
+
+// Объект текущей стратегии
+var st = selector{strategy: &cooldownStrategy{}}
+
+// Выбор стратегии по названию
+func SetStrategy(name string) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	create, ok := Strategies[name]
+	if !ok {
+		return fmt.Errorf("стратегия выбора прокси %q не поддерживается", name)
+	}
+
+	UseStrategy(create())
+
+	return nil
+}
+
+// Установка собственной стратегии
+func UseStrategy(strategy Strategy) {
+	st.m.Lock()
+	defer st.m.Unlock()
+
+	st.strategy = strategy
+}
+
+// Получение текущей стратегии
+func CurrentStrategy() Strategy {
+	st.m.RLock()
+	defer st.m.RUnlock()
+
+	return st.strategy
+}
+
+// Выбор прокси текущей стратегией
+func Select(key string) string {
+	p.m.Lock()
+	isEnabled := p.isEnabled
+	p.m.Unlock()
+
+	if !isEnabled {
+		return ""
+	}
+
+	return CurrentStrategy().Select(key)
+}
+
+// Сброс состояния текущей стратегии
+func resetStrategy() {
+	st.m.Lock()
+	defer st.m.Unlock()
+
+	if r, ok := st.strategy.(interface{ reset() }); ok {
+		r.reset()
+	}
+}
+
+// Доступные прокси (прямое соединение и прокси не в карантине)
+func candidates() []string {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	list := make([]string, 0, len(p.proxies))
+	for _, proxy := range p.proxies {
+		if proxy == "" || !IsQuarantined(proxy) {
+			list = append(list, proxy)
+		}
+	}
+
+	return list
+}
+
+// По кругу с порогами и возвратом на прямое соединение
+type cooldownStrategy struct{}
+
+func (s *cooldownStrategy) Name() string {
+	return StrategyCooldown
+}
+
+func (s *cooldownStrategy) Select(key string) string {
+	return Cooldown()
+}
+
+// По кругу на каждый запрос
+type roundRobinStrategy struct {
+	n int
+	m sync.Mutex
+}
+
+func (s *roundRobinStrategy) Name() string {
+	return StrategyRoundRobin
+}
+
+func (s *roundRobinStrategy) Select(key string) string {
+	list := candidates()
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	proxy := list[s.n%len(list)]
+	s.n++
+
+	return proxy
+}
+
+func (s *roundRobinStrategy) reset() {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.n = 0
+}
+
+// Давно не использованный
+type lruStrategy struct {
+	used map[string]time.Time
+	m    sync.Mutex
+}
+
+func (s *lruStrategy) Name() string {
+	return StrategyLRU
+}
+
+func (s *lruStrategy) Select(key string) string {
+	list := candidates()
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	// Устойчивая сортировка - среди неиспользованных первым идет прямое соединение
+	sort.SliceStable(list, func(i, j int) bool {
+		return s.used[list[i]].Before(s.used[list[j]])
+	})
+
+	proxy := list[0]
+	s.used[proxy] = time.Now()
+
+	return proxy
+}
+
+func (s *lruStrategy) reset() {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.used = make(map[string]time.Time)
+}
+
+// Случайный с весом по оценке
+type weightedStrategy struct{}
+
+func (s *weightedStrategy) Name() string {
+	return StrategyWeighted
+}
+
+func (s *weightedStrategy) Select(key string) string {
+	var (
+		list    = candidates()
+		weights = make([]float64, len(list))
+		total   float64
+	)
+
+	for i, proxy := range list {
+		weights[i] = Score(proxy)
+		total += weights[i]
+	}
+
+	if total <= 0 {
+		return list[rand.Intn(len(list))]
+	}
+
+	r := rand.Float64() * total
+	for i, w := range weights {
+		if r < w {
+			return list[i]
+		}
+		r -= w
+	}
+
+	return list[len(list)-1]
+}
+
+// Закрепленный за каналом (страницы одного канала запрашиваются с одного адреса).
+// Прокси канала определяется хешем ключа по текущему списку (rendezvous hashing),
+// так что каналы не запоминаются, а при выбытии прокси переезжают только его каналы
+type stickyStrategy struct {
+	n int
+	m sync.Mutex
+}
+
+func (s *stickyStrategy) Name() string {
+	return StrategySticky
+}
+
+func (s *stickyStrategy) Select(key string) string {
+	list := candidates()
+
+	// Без ключа - по кругу
+	if key == "" {
+		s.m.Lock()
+		defer s.m.Unlock()
+
+		proxy := list[s.n%len(list)]
+		s.n++
+
+		return proxy
+	}
+
+	var (
+		proxy string
+		max   uint64
+	)
+
+	for i, candidate := range list {
+		if weight := rendezvous(key, candidate); i == 0 || weight > max {
+			proxy, max = candidate, weight
+		}
+	}
+
+	return proxy
+}
+
+// При исчерпании лимита прокси канала запрос ждет его, а не уходит через другой
+func (s *stickyStrategy) pinned() {}
+
+func (s *stickyStrategy) reset() {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.n = 0
+}
+
+// Вес пары ключа и прокси для rendezvous hashing
+func rendezvous(key, proxy string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(proxy))
+
+	return h.Sum64()
+}
+
+// Прямое соединение, при исчерпании его лимита - прокси по кругу
+type directFirstStrategy struct {
+	n int
+	m sync.Mutex
+}
+
+func (s *directFirstStrategy) Name() string {
+	return StrategyDirectFirst
+}
+
+func (s *directFirstStrategy) Select(key string) string {
+	if tokens := Tokens(""); tokens < 0 || tokens >= 1 {
+		return ""
+	}
+
+	list := candidates()[1:]
+	if len(list) == 0 {
+		return ""
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	proxy := list[s.n%len(list)]
+	s.n++
+
+	return proxy
+}
+
+func (s *directFirstStrategy) reset() {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.n = 0
+}
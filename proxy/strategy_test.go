@@ -0,0 +1,208 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSetStrategy(t *testing.T) {
+	defer UseStrategy(&cooldownStrategy{})
+
+	tests := []struct {
+		test   string
+		value  string
+		result string
+		isErr  bool
+	}{
+		{"Cooldown", "cooldown", StrategyCooldown, false},
+		{"RoundRobin", "Round-Robin", StrategyRoundRobin, false},
+		{"LRU", " lru ", StrategyLRU, false},
+		{"Weighted", "weighted", StrategyWeighted, false},
+		{"Sticky", "sticky", StrategySticky, false},
+		{"DirectFirst", "direct-first", StrategyDirectFirst, false},
+		{"Unknown", "random", StrategyDirectFirst, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			err := SetStrategy(tt.value)
+
+			if (err != nil) != tt.isErr {
+				t.Fatalf("Получена ошибка: %v", err)
+			}
+			if result := CurrentStrategy().Name(); result != tt.result {
+				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
+			}
+		})
+	}
+}
+
+func TestStrategies(t *testing.T) {
+	defer UseStrategy(&cooldownStrategy{})
+	defer DisableLimits()
+	defer Disable()
+
+	var (
+		direct = ""
+		first  = "http://164.92.180.67:8080"
+		second = "https://105.112.191.250:3128"
+		third  = "http://186.232.119.58:3128"
+	)
+
+	tests := []struct {
+		test     string
+		strategy string
+		prepare  func()
+		keys     []string
+		result   []string
+	}{
+		{"RoundRobin", StrategyRoundRobin, func() {}, []string{"", "", "", "", ""},
+			[]string{direct, first, second, third, direct}},
+		{"RoundRobinQuarantined", StrategyRoundRobin, func() {
+			for i := 0; i < QuarantineFailures; i++ {
+				Record(first, 0, false, errors.New("timeout"))
+			}
+		}, []string{"", "", "", ""}, []string{direct, second, third, direct}},
+		{"LRU", StrategyLRU, func() {}, []string{"", "", "", "", ""},
+			[]string{direct, first, second, third, direct}},
+		{"StickyNoKey", StrategySticky, func() {}, []string{"", "", "", "", ""},
+			[]string{direct, first, second, third, direct}},
+		{"DirectFirst", StrategyDirectFirst, func() {
+			SetRate(ClassDirect, 60, 2)
+		}, []string{"", "", "", "", "", ""}, []string{direct, direct, first, second, third, first}},
+		{"DirectFirstUnlimited", StrategyDirectFirst, func() {}, []string{"", "", ""},
+			[]string{direct, direct, direct}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			SetStrategy(tt.strategy)
+			Clear()
+			DisableLimits()
+			PrepareFromList(validProxies)
+			Enable(mainThreshold, proxyThreshold, cooldown)
+			tt.prepare()
+
+			for i, key := range tt.keys {
+				// Прокси канала "b" уходит в карантин
+				if key == "quarantine" {
+					for j := 0; j < QuarantineFailures; j++ {
+						Record(first, 0, false, errors.New("timeout"))
+					}
+					key = "c"
+				}
+
+				// Прямое соединение расходует токен лимита, как в Take
+				result := Select(key)
+				if result == "" {
+					take(result)
+				}
+
+				if result != tt.result[i] {
+					t.Errorf("Запрос %d - получено значение: %q, ожидается: %q", i+1, result, tt.result[i])
+				}
+			}
+		})
+	}
+}
+
+func TestStrategySticky(t *testing.T) {
+	defer UseStrategy(&cooldownStrategy{})
+	defer Disable()
+
+	SetStrategy(StrategySticky)
+	Clear()
+	PrepareFromList(validProxies)
+	Enable(mainThreshold, proxyThreshold, cooldown)
+
+	keys := make([]string, 100)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("channel%d", i)
+	}
+
+	// Каналы распределяются по всем адресам и закрепляются за ними
+	assigned := make(map[string]string)
+	counts := make(map[string]int)
+
+	for _, key := range keys {
+		assigned[key] = Select(key)
+		counts[assigned[key]]++
+	}
+
+	for _, key := range keys {
+		if result := Select(key); result != assigned[key] {
+			t.Fatalf("Канал %s - получено значение: %q, ожидается: %q", key, result, assigned[key])
+		}
+	}
+	if len(counts) != len(p.proxies) {
+		t.Errorf("Каналы распределены не по всем адресам: %v", counts)
+	}
+
+	// Прокси в карантине - переезжают только его каналы
+	quarantined := p.proxies[1]
+	for i := 0; i < QuarantineFailures; i++ {
+		Record(quarantined, 0, false, errors.New("timeout"))
+	}
+
+	for _, key := range keys {
+		result := Select(key)
+
+		switch {
+		case result == quarantined:
+			t.Errorf("Канал %s - получен прокси в карантине", key)
+		case assigned[key] != quarantined && result != assigned[key]:
+			t.Errorf("Канал %s - получено значение: %q, ожидается: %q", key, result, assigned[key])
+		}
+	}
+}
+
+func TestStrategyWeighted(t *testing.T) {
+	defer UseStrategy(&cooldownStrategy{})
+	defer Disable()
+
+	SetStrategy(StrategyWeighted)
+	Clear()
+	PrepareFromList(validProxies)
+	Enable(mainThreshold, proxyThreshold, cooldown)
+
+	first, second, third := p.proxies[1], p.proxies[2], p.proxies[3]
+
+	Record("", 3*time.Second, false, nil)
+	Record(first, 100*time.Millisecond, false, nil)
+	Record(second, 3*time.Second, true, nil)
+	for i := 0; i < QuarantineFailures; i++ {
+		Record(third, 0, false, errors.New("timeout"))
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		counts[Select("")]++
+	}
+
+	if counts[third] != 0 {
+		t.Errorf("Прокси в карантине выбран %d раз", counts[third])
+	}
+	if counts[first] <= counts[second] || counts[first] <= counts[""] {
+		t.Errorf("Быстрый прокси выбран реже остальных: %v", counts)
+	}
+	if counts[second] == 0 || counts[""] == 0 {
+		t.Errorf("Медленные прокси не выбраны ни разу: %v", counts)
+	}
+}
+
+func TestSelectDisabled(t *testing.T) {
+	defer UseStrategy(&cooldownStrategy{})
+
+	SetStrategy(StrategyRoundRobin)
+	Clear()
+	PrepareFromList(validProxies)
+	Disable()
+
+	for i := 0; i < 3; i++ {
+		if result := Select(""); result != "" {
+			t.Errorf("Получено значение: %q, ожидается прямое соединение", result)
+		}
+	}
+}
@@ -79,6 +79,16 @@ func ParamBool(req *http.Request, fieldName string, defaultValue bool) bool {
 	return res
 }
 
+// Получение поля запроса со временем
+func ParamTime(req *http.Request, fieldName string, defaultValue time.Time) time.Time {
+	res, err := check.Time(req.FormValue(fieldName))
+	if err != nil {
+		res = defaultValue
+	}
+
+	return res
+}
+
 // Получение строкового поля запроса
 func ParamString(req *http.Request, fieldName string, defaultValue string) string {
 	res := req.FormValue(fieldName)
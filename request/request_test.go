@@ -149,6 +149,38 @@ func TestParamBool(t *testing.T) {
 	}
 }
 
+func TestParamTime(t *testing.T) {
+	def := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		test   string
+		key    string
+		get    interface{}
+		result time.Time
+	}{
+		{"Date", "from", "2024-03-01", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"RFC3339", "to", "2024-03-01T10:00:00Z", time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)},
+		{"Wrong", "wrong", "yesterday", def},
+		{"Empty", "", "2024-03-01", def},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			request := mock.NewRequest()
+
+			if tt.key != "" {
+				request.AddParamGET(tt.key, tt.get)
+			}
+
+			result := ParamTime(&request.Request, tt.key, def)
+
+			if !result.Equal(tt.result) {
+				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
+			}
+		})
+	}
+}
+
 func TestParamString(t *testing.T) {
 	tests := []struct {
 		test   string
@@ -0,0 +1,233 @@
+package series
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"statosphere/parser/storage"
+)
+
+// Точки кривой набора просмотров сообщения (время после публикации)
+var AccrualPoints = []struct {
+	Name  string
+	After time.Duration
+}{
+	{"1h", time.Hour},
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+}
+
+// Точка временного ряда
+type Point struct {
+	Time  time.Time `json:"time"`
+	Value uint      `json:"value"`
+}
+
+// Временной ряд показателя (числа подписчиков канала или просмотров сообщения)
+type Series struct {
+	Peer      string  `json:"peer"`
+	MessageID uint    `json:"messageId,omitempty"` // 0 - число подписчиков канала
+	Points    []Point `json:"points"`              // От старых к новым
+}
+
+// Рост показателя за период (по первой и последней точкам ряда)
+type Growth struct {
+	Peer         string    `json:"peer"`
+	MessageID    uint      `json:"messageId,omitempty"`
+	From         time.Time `json:"from"`
+	To           time.Time `json:"to"`
+	Start        uint      `json:"start"`
+	End          uint      `json:"end"`
+	Absolute     int64     `json:"absolute"`
+	Percent      float64   `json:"percent"`      // 0 - при нулевом начальном значении
+	DailyAverage float64   `json:"dailyAverage"` // 0 - при периоде в одну точку
+}
+
+// Кривая набора просмотров сообщения
+type Accrual struct {
+	Peer      string         `json:"peer"`
+	MessageID uint           `json:"messageId"`
+	Date      time.Time      `json:"date"`
+	Views     []AccrualPoint `json:"views"` // Только точки, которые уже наступили
+}
+
+// Просмотры сообщения через заданное время после публикации
+type AccrualPoint struct {
+	After          string    `json:"after"`
+	Time           time.Time `json:"time"`
+	Value          uint      `json:"value"`
+	IsExtrapolated bool      `json:"isExtrapolated,omitempty"` // Снимки есть не с обеих сторон (до первого - от нуля при публикации, после последнего - без изменений)
+}
+
+// Ряд из снимков (только снимки заданного сообщения или, при 0, числа подписчиков)
+func FromSnapshots(peer string, messageID uint, snapshots []storage.Snapshot) Series {
+	sr := Series{Peer: peer, MessageID: messageID, Points: []Point{}}
+
+	for _, s := range snapshots {
+		if s.MessageID == messageID {
+			sr.Points = append(sr.Points, Point{Time: s.Time, Value: s.Value.Value()})
+		}
+	}
+
+	sort.SliceStable(sr.Points, func(i, j int) bool {
+		return sr.Points[i].Time.Before(sr.Points[j].Time)
+	})
+
+	return sr
+}
+
+// Ряд из снимков хранилища за период (хранилище не повторяет неизменные снимки, поэтому
+// последнее значение до периода переносится на его начало, а последнее значение ряда - на конец)
+func Load(s storage.Store, peer string, messageID uint, from, to time.Time) (Series, error) {
+	snapshots, err := s.Snapshots(peer, from, to)
+	if err != nil {
+		return Series{}, err
+	}
+
+	sr := FromSnapshots(peer, messageID, snapshots)
+
+	if !from.IsZero() && (len(sr.Points) == 0 || sr.Points[0].Time.After(from)) {
+		before, err := s.Snapshots(peer, time.Time{}, from)
+		if err != nil {
+			return Series{}, err
+		}
+
+		if prev := FromSnapshots(peer, messageID, before); len(prev.Points) > 0 {
+			sr.Points = append([]Point{{Time: from, Value: prev.Points[len(prev.Points)-1].Value}}, sr.Points...)
+		}
+	}
+
+	sr.extend(endOf(to))
+
+	return sr, nil
+}
+
+// Продление последнего значения ряда до заданного времени
+func (sr *Series) extend(end time.Time) {
+	n := len(sr.Points)
+	if n > 0 && sr.Points[n-1].Time.Before(end) {
+		sr.Points = append(sr.Points, Point{Time: end, Value: sr.Points[n-1].Value})
+	}
+}
+
+// Конец периода (без границы или в будущем - текущее время)
+func endOf(to time.Time) time.Time {
+	if now := time.Now(); to.IsZero() || to.After(now) {
+		return now
+	}
+
+	return to
+}
+
+// Значение на момент времени (линейно между соседними точками, вне ряда - неизвестно)
+func (sr Series) At(t time.Time) (uint, bool) {
+	n := len(sr.Points)
+	if n == 0 || t.Before(sr.Points[0].Time) || t.After(sr.Points[n-1].Time) {
+		return 0, false
+	}
+
+	i := sort.Search(n, func(i int) bool {
+		return !sr.Points[i].Time.Before(t)
+	})
+
+	next := sr.Points[i]
+	if next.Time.Equal(t) || i == 0 {
+		return next.Value, true
+	}
+
+	prev := sr.Points[i-1]
+	share := float64(t.Sub(prev.Time)) / float64(next.Time.Sub(prev.Time))
+
+	return uint(float64(prev.Value) + share*(float64(next.Value)-float64(prev.Value)) + 0.5), true
+}
+
+// Рост показателя по ряду (ряд без точек - без роста)
+func (sr Series) Growth() (Growth, bool) {
+	n := len(sr.Points)
+	if n == 0 {
+		return Growth{}, false
+	}
+
+	first, last := sr.Points[0], sr.Points[n-1]
+
+	g := Growth{
+		Peer:      sr.Peer,
+		MessageID: sr.MessageID,
+		From:      first.Time,
+		To:        last.Time,
+		Start:     first.Value,
+		End:       last.Value,
+		Absolute:  int64(last.Value) - int64(first.Value),
+	}
+
+	if first.Value > 0 {
+		g.Percent = float64(g.Absolute) / float64(first.Value) * 100
+	}
+	if days := last.Time.Sub(first.Time).Hours() / 24; days > 0 {
+		g.DailyAverage = float64(g.Absolute) / days
+	}
+
+	return g, true
+}
+
+// Запись ряда в CSV (время в RFC 3339 и значение)
+func (sr Series) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	cw.Write([]string{"time", "value"})
+	for _, p := range sr.Points {
+		cw.Write([]string{p.Time.UTC().Format(time.RFC3339), strconv.FormatUint(uint64(p.Value), 10)})
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// Кривые набора просмотров сообщений, опубликованных за период (точка между снимками вычисляется по ним,
+// а до первого снимка и после последнего - оценивается и отмечается как экстраполированная)
+func Accruals(s storage.Store, peer string, from, to time.Time) ([]Accrual, error) {
+	messages, err := s.Messages(peer, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := s.Snapshots(peer, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	accruals := make([]Accrual, 0, len(messages))
+
+	for _, m := range messages {
+		if m.Date.IsZero() {
+			continue
+		}
+
+		// Оценка: от нуля при публикации до первого снимка и без изменений после последнего до текущего времени
+		sr := FromSnapshots(peer, m.ID, snapshots)
+		estimate := Series{Points: append([]Point(nil), sr.Points...)}
+		if len(estimate.Points) > 0 && estimate.Points[0].Time.After(m.Date) {
+			estimate.Points = append([]Point{{Time: m.Date}}, estimate.Points...)
+		}
+		estimate.extend(time.Now())
+
+		a := Accrual{Peer: peer, MessageID: m.ID, Date: m.Date, Views: []AccrualPoint{}}
+
+		for _, p := range AccrualPoints {
+			t := m.Date.Add(p.After)
+			if v, ok := sr.At(t); ok {
+				a.Views = append(a.Views, AccrualPoint{After: p.Name, Time: t, Value: v})
+			} else if v, ok := estimate.At(t); ok {
+				a.Views = append(a.Views, AccrualPoint{After: p.Name, Time: t, Value: v, IsExtrapolated: true})
+			}
+		}
+
+		accruals = append(accruals, a)
+	}
+
+	return accruals, nil
+}
@@ -0,0 +1,182 @@
+package series
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"statosphere/parser/message"
+	"statosphere/parser/storage"
+	"statosphere/parser/value"
+)
+
+var base = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// Снимок с заданным значением через заданное время от base
+func snapshot(messageID, views uint, after time.Duration) storage.Snapshot {
+	return storage.Snapshot{Peer: "@codecamp", MessageID: messageID, Value: value.Value{Exact: views}, Time: base.Add(after)}
+}
+
+func TestAt(t *testing.T) {
+	sr := FromSnapshots("@codecamp", 0, []storage.Snapshot{
+		snapshot(0, 200, 2*time.Hour), snapshot(0, 100, 0), snapshot(1, 5, time.Hour),
+	})
+
+	tests := []struct {
+		test   string
+		value  time.Duration
+		result uint
+		isOk   bool
+	}{
+		{"First", 0, 100, true},
+		{"Middle", 30 * time.Minute, 125, true},
+		{"Last", 2 * time.Hour, 200, true},
+		{"Before", -time.Minute, 0, false},
+		{"After", 3 * time.Hour, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			result, ok := sr.At(base.Add(tt.value))
+
+			if result != tt.result || ok != tt.isOk {
+				t.Errorf("Получено значение: %v (%v), ожидается: %v (%v)", result, ok, tt.result, tt.isOk)
+			}
+		})
+	}
+}
+
+func TestGrowth(t *testing.T) {
+	tests := []struct {
+		test      string
+		snapshots []storage.Snapshot
+		result    Growth
+		isOk      bool
+	}{
+		{"Growth", []storage.Snapshot{snapshot(0, 100, 0), snapshot(0, 110, 24*time.Hour), snapshot(0, 150, 48*time.Hour)},
+			Growth{Peer: "@codecamp", From: base, To: base.Add(48 * time.Hour), Start: 100, End: 150,
+				Absolute: 50, Percent: 50, DailyAverage: 25}, true},
+		{"Decline", []storage.Snapshot{snapshot(0, 200, 0), snapshot(0, 150, 12*time.Hour)},
+			Growth{Peer: "@codecamp", From: base, To: base.Add(12 * time.Hour), Start: 200, End: 150,
+				Absolute: -50, Percent: -25, DailyAverage: -100}, true},
+		{"Single", []storage.Snapshot{snapshot(0, 100, 0)},
+			Growth{Peer: "@codecamp", From: base, To: base, Start: 100, End: 100}, true},
+		{"Empty", nil, Growth{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			result, ok := FromSnapshots("@codecamp", 0, tt.snapshots).Growth()
+
+			if ok != tt.isOk || !reflect.DeepEqual(result, tt.result) {
+				t.Errorf("Получено значение: %+v, ожидается: %+v", result, tt.result)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	s, err := storage.OpenFile(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	defer s.Close()
+
+	// Неизменные снимки хранилище пропускает: 120 держится с 24 до 72 часов
+	s.AppendSnapshots(
+		snapshot(0, 100, 0), snapshot(0, 120, 24*time.Hour),
+		snapshot(0, 120, 48*time.Hour), snapshot(0, 150, 72*time.Hour),
+	)
+
+	tests := []struct {
+		test   string
+		from   time.Duration
+		to     time.Duration
+		result Growth
+	}{
+		{"Flat", 36 * time.Hour, 60 * time.Hour,
+			Growth{Peer: "@codecamp", From: base.Add(36 * time.Hour), To: base.Add(60 * time.Hour), Start: 120, End: 120}},
+		{"ChangedBefore", 12 * time.Hour, 60 * time.Hour,
+			Growth{Peer: "@codecamp", From: base.Add(12 * time.Hour), To: base.Add(60 * time.Hour), Start: 100, End: 120,
+				Absolute: 20, Percent: 20, DailyAverage: 10}},
+		{"Changed", 48 * time.Hour, 96 * time.Hour,
+			Growth{Peer: "@codecamp", From: base.Add(48 * time.Hour), To: base.Add(96 * time.Hour), Start: 120, End: 150,
+				Absolute: 30, Percent: 25, DailyAverage: 15}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			sr, err := Load(s, "@codecamp", 0, base.Add(tt.from), base.Add(tt.to))
+			if err != nil {
+				t.Fatalf("Получена ошибка: %v", err)
+			}
+
+			result, ok := sr.Growth()
+
+			if !ok || !reflect.DeepEqual(result, tt.result) {
+				t.Errorf("Получено значение: %+v, ожидается: %+v", result, tt.result)
+			}
+		})
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	sr := FromSnapshots("@codecamp", 0, []storage.Snapshot{snapshot(0, 100, 0), snapshot(0, 120, time.Hour)})
+
+	var b bytes.Buffer
+	if err := sr.WriteCSV(&b); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	result := b.String()
+	expected := "time,value\n2024-03-01T12:00:00Z,100\n2024-03-01T13:00:00Z,120\n"
+
+	if result != expected {
+		t.Errorf("Получено значение: %v, ожидается: %v", result, expected)
+	}
+}
+
+func TestAccruals(t *testing.T) {
+	s, err := storage.OpenFile(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	defer s.Close()
+
+	// Сообщение 1 - со снимками до 8 дней, 2 - только через 2 часа после публикации (дальше просмотры не менялись)
+	s.UpsertMessages("@codecamp", []*message.Message{{ID: 1, Date: base}, {ID: 2, Date: base.Add(time.Hour)}})
+	s.AppendSnapshots(
+		snapshot(1, 100, 30*time.Minute), snapshot(1, 300, 90*time.Minute),
+		snapshot(1, 1000, 24*time.Hour), snapshot(1, 2000, 8*24*time.Hour),
+		snapshot(2, 80, 3*time.Hour),
+	)
+
+	accruals, err := Accruals(s, "@codecamp", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	tests := []struct {
+		test   string
+		value  Accrual
+		result []AccrualPoint
+	}{
+		{"Full", accruals[1], []AccrualPoint{
+			{"1h", base.Add(time.Hour), 200, false},
+			{"24h", base.Add(24 * time.Hour), 1000, false},
+			{"7d", base.Add(7 * 24 * time.Hour), 1857, false}}},
+		{"Extrapolated", accruals[0], []AccrualPoint{
+			{"1h", base.Add(2 * time.Hour), 40, true},
+			{"24h", base.Add(25 * time.Hour), 80, true},
+			{"7d", base.Add(7*24*time.Hour + time.Hour), 80, true}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.value.Views, tt.result) {
+				t.Errorf("Получено значение: %v, ожидается: %v", tt.value.Views, tt.result)
+			}
+		})
+	}
+}
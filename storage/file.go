@@ -0,0 +1,608 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"statosphere/parser/channel"
+	"statosphere/parser/message"
+	"statosphere/parser/value"
+)
+
+// Размер сегмента по умолчанию (после него записи идут в новый сегмент)
+const DefaultSegmentSize int64 = 64 << 20
+
+// Виды записей
+const (
+	kindChannel  = "channel"
+	kindMessage  = "message"
+	kindSnapshot = "snapshot"
+	kindRemoved  = "removed" // Отметка удаленного сообщения (сообщение исключается из индекса)
+)
+
+// Ошибка закрытого хранилища
+var ErrClosed = errors.New("хранилище закрыто")
+
+// Хранилище в папке с сегментами, в которые записи только добавляются (индекс - в памяти, строится при открытии)
+type File struct {
+	dir         string
+	segmentSize int64
+	files       map[int]*os.File // Сегменты по номерам
+	active      int              // Номер сегмента для записи
+	activeSize  int64
+	channels    map[string]location
+	messages    map[string]map[uint]messageLocation
+	snapshots   map[string][]snapshotLocation
+	last        map[snapshotKey]value.Value // Последние значения снимков (повторы не записываются)
+	skipped     int                         // Пропущенные поврежденные записи
+	isClosed    bool
+	m           sync.RWMutex
+}
+
+// Запись в сегменте
+type record struct {
+	Kind string          `json:"kind"`
+	Peer string          `json:"peer"`
+	ID   uint            `json:"id,omitempty"`   // ID сообщения
+	Time time.Time       `json:"time,omitempty"` // Дата сообщения или время снимка
+	Data json.RawMessage `json:"data"`
+}
+
+// Расположение записи
+type location struct {
+	segment int
+	offset  int64
+	size    int
+	crc     uint32 // Контрольная сумма данных (одинаковые данные не записываются повторно)
+}
+
+// Расположение сообщения
+type messageLocation struct {
+	location
+	id   uint
+	date time.Time
+}
+
+// Расположение снимка
+type snapshotLocation struct {
+	location
+	time time.Time
+}
+
+// Ключ последнего значения снимка
+type snapshotKey struct {
+	peer      string
+	messageID uint
+}
+
+// Открытие хранилища (папка создается, размер сегмента 0 - по умолчанию)
+func OpenFile(dir string, segmentSize int64) (*File, error) {
+	if segmentSize <= 0 {
+		segmentSize = DefaultSegmentSize
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	f := &File{
+		dir:         dir,
+		segmentSize: segmentSize,
+		files:       make(map[int]*os.File),
+		channels:    make(map[string]location),
+		messages:    make(map[string]map[uint]messageLocation),
+		snapshots:   make(map[string][]snapshotLocation),
+		last:        make(map[snapshotKey]value.Value),
+	}
+
+	segments, err := f.segments()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, segment := range segments {
+		if err := f.load(segment, i == len(segments)-1); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	// Новое хранилище - с первого сегмента
+	if len(segments) == 0 {
+		if err := f.rotate(1); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// Имя файла сегмента
+func (f *File) segmentName(segment int) string {
+	return filepath.Join(f.dir, fmt.Sprintf("segment-%06d.log", segment))
+}
+
+// Номера сегментов (по порядку)
+func (f *File) segments() ([]int, error) {
+	names, err := filepath.Glob(filepath.Join(f.dir, "segment-*.log"))
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []int
+	for _, name := range names {
+		n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(filepath.Base(name), "segment-"), ".log"))
+		if err == nil && n > 0 {
+			segments = append(segments, n)
+		}
+	}
+
+	sort.Ints(segments)
+
+	return segments, nil
+}
+
+// Чтение сегмента в индекс (недописанная запись в конце последнего сегмента отрезается)
+func (f *File) load(segment int, isLast bool) error {
+	file, err := os.OpenFile(f.segmentName(segment), os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	f.files[segment] = file
+
+	var (
+		reader = bufio.NewReader(file)
+		offset int64
+	)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+
+		// Конец сегмента (возможно, с недописанной записью после сбоя)
+		if err == io.EOF {
+			if len(line) > 0 {
+				if !isLast {
+					f.skipped++
+				} else if err := file.Truncate(offset); err != nil {
+					return err
+				}
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		loc := location{segment: segment, offset: offset, size: len(line)}
+		offset += int64(len(line))
+
+		rec, crc, err := decodeRecord(line)
+		if err != nil {
+			f.skipped++
+			continue
+		}
+
+		loc.crc = crc
+		f.index(rec, loc)
+	}
+
+	if !isLast {
+		return nil
+	}
+
+	// Последний сегмент - для записи (в конец, после отрезанной записи)
+	file.Close()
+
+	return f.rotate(segment)
+}
+
+// Добавление записи в индекс (без блокировки)
+func (f *File) index(rec record, loc location) {
+	peer := Key(rec.Peer)
+
+	switch rec.Kind {
+	case kindChannel:
+		f.channels[peer] = loc
+
+	case kindMessage:
+		if f.messages[peer] == nil {
+			f.messages[peer] = make(map[uint]messageLocation)
+		}
+		f.messages[peer][rec.ID] = messageLocation{loc, rec.ID, rec.Time}
+
+	case kindRemoved:
+		delete(f.messages[peer], rec.ID)
+
+	case kindSnapshot:
+		f.snapshots[peer] = append(f.snapshots[peer], snapshotLocation{loc, rec.Time})
+
+		var s Snapshot
+		if json.Unmarshal(rec.Data, &s) == nil {
+			f.last[snapshotKey{peer, s.MessageID}] = s.Value
+		}
+	}
+}
+
+// Запись в виде строки сегмента (контрольная сумма, JSON и перевод строки)
+func encodeRecord(rec record) ([]byte, uint32, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return []byte(fmt.Sprintf("%08x %s\n", crc32.ChecksumIEEE(data), data)), crc32.ChecksumIEEE(rec.Data), nil
+}
+
+// Запись из строки сегмента (с проверкой контрольной суммы)
+func decodeRecord(line []byte) (record, uint32, error) {
+	var rec record
+
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	if len(line) < 10 || line[8] != ' ' {
+		return rec, 0, errors.New("запись повреждена")
+	}
+
+	sum, err := strconv.ParseUint(string(line[:8]), 16, 32)
+	if err != nil || uint32(sum) != crc32.ChecksumIEEE(line[9:]) {
+		return rec, 0, errors.New("контрольная сумма не совпадает")
+	}
+
+	if err := json.Unmarshal(line[9:], &rec); err != nil {
+		return rec, 0, err
+	}
+
+	return rec, crc32.ChecksumIEEE(rec.Data), nil
+}
+
+// Переход к новому сегменту (без блокировки)
+func (f *File) rotate(segment int) error {
+	file, err := os.OpenFile(f.segmentName(segment), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	if prev, ok := f.files[f.active]; ok && f.active != segment {
+		prev.Sync()
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.files[segment] = file
+	f.active, f.activeSize = segment, info.Size()
+
+	return nil
+}
+
+// Запись в активный сегмент одним блоком (без блокировки)
+func (f *File) write(records []record) error {
+	if f.isClosed {
+		return ErrClosed
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	var (
+		buf  bytes.Buffer
+		locs = make([]location, 0, len(records))
+	)
+
+	for _, rec := range records {
+		line, crc, err := encodeRecord(rec)
+		if err != nil {
+			return err
+		}
+
+		locs = append(locs, location{offset: int64(buf.Len()), size: len(line), crc: crc})
+		buf.Write(line)
+	}
+
+	if f.activeSize > 0 && f.activeSize+int64(buf.Len()) > f.segmentSize {
+		if err := f.rotate(f.active + 1); err != nil {
+			return err
+		}
+	}
+
+	// Сегмент открыт на добавление - запись идет в конец
+	file := f.files[f.active]
+
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		// Частично записанный блок отрезается
+		file.Truncate(f.activeSize)
+		return err
+	}
+
+	for i, rec := range records {
+		locs[i].segment = f.active
+		locs[i].offset += f.activeSize
+		f.index(rec, locs[i])
+	}
+
+	f.activeSize += int64(buf.Len())
+
+	return nil
+}
+
+// Чтение данных записи (без блокировки)
+func (f *File) read(loc location, v any) error {
+	file, ok := f.files[loc.segment]
+	if !ok {
+		return fmt.Errorf("сегмент %d не найден", loc.segment)
+	}
+
+	line := make([]byte, loc.size)
+	if _, err := file.ReadAt(line, loc.offset); err != nil {
+		return err
+	}
+
+	rec, _, err := decodeRecord(line)
+	if err != nil {
+		return fmt.Errorf("сегмент %d, смещение %d: %w", loc.segment, loc.offset, err)
+	}
+
+	return json.Unmarshal(rec.Data, v)
+}
+
+// Запись канала (без сообщений и отметок кэша, неизмененный канал не записывается)
+func (f *File) UpsertChannel(c *channel.Channel) error {
+	if c.Peer == "" {
+		return errors.New("канал без peer")
+	}
+
+	data := *c
+	data.Messages = nil
+	data.IsStale, data.Age = false, 0
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if loc, ok := f.channels[Key(c.Peer)]; ok && loc.crc == crc32.ChecksumIEEE(raw) {
+		return nil
+	}
+
+	return f.write([]record{{Kind: kindChannel, Peer: c.Peer, Data: raw}})
+}
+
+// Запись сообщений (неизмененные сообщения не записываются, просмотры хранятся только в снимках,
+// чтобы сообщение не записывалось заново при каждом их изменении)
+func (f *File) UpsertMessages(peer string, messages []*message.Message) error {
+	if peer == "" {
+		return errors.New("сообщения без peer")
+	}
+
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	var records []record
+
+	for _, m := range messages {
+		if m == nil || m.ID == 0 {
+			continue
+		}
+
+		data := *m
+		data.Views = value.Value{}
+
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+
+		if loc, ok := f.messages[Key(peer)][m.ID]; ok && loc.crc == crc32.ChecksumIEEE(raw) {
+			continue
+		}
+
+		records = append(records, record{Kind: kindMessage, Peer: peer, ID: m.ID, Time: m.Date, Data: raw})
+	}
+
+	return f.write(records)
+}
+
+// Отметка сообщений удаленными (отметки неизвестных сообщений не записываются, сообщение, полученное снова, записывается заново)
+func (f *File) RemoveMessages(peer string, ids []uint) error {
+	if peer == "" {
+		return errors.New("сообщения без peer")
+	}
+
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	var records []record
+
+	for _, id := range ids {
+		if _, ok := f.messages[Key(peer)][id]; !ok {
+			continue
+		}
+
+		records = append(records, record{Kind: kindRemoved, Peer: peer, ID: id, Data: json.RawMessage("null")})
+	}
+
+	return f.write(records)
+}
+
+// Добавление снимков (снимок, равный последнему для того же канала и сообщения, не записывается)
+func (f *File) AppendSnapshots(snapshots ...Snapshot) error {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	var (
+		records []record
+		last    = make(map[snapshotKey]value.Value)
+	)
+
+	for _, s := range snapshots {
+		if s.Peer == "" {
+			return errors.New("снимок без peer")
+		}
+
+		key := snapshotKey{Key(s.Peer), s.MessageID}
+
+		prev, ok := last[key]
+		if !ok {
+			prev, ok = f.last[key]
+		}
+		if ok && prev == s.Value {
+			continue
+		}
+		last[key] = s.Value
+
+		raw, err := json.Marshal(s)
+		if err != nil {
+			return err
+		}
+
+		records = append(records, record{Kind: kindSnapshot, Peer: s.Peer, ID: s.MessageID, Time: s.Time, Data: raw})
+	}
+
+	return f.write(records)
+}
+
+// Канал (без сообщений)
+func (f *File) Channel(peer string) (*channel.Channel, bool, error) {
+	f.m.RLock()
+	defer f.m.RUnlock()
+
+	loc, ok := f.channels[Key(peer)]
+	if !ok {
+		return nil, false, nil
+	}
+
+	var c channel.Channel
+	if err := f.read(loc, &c); err != nil {
+		return nil, false, err
+	}
+
+	return &c, true, nil
+}
+
+// Сообщения за период по дате публикации (от новых к старым, просмотры - из последнего снимка)
+func (f *File) Messages(peer string, from, to time.Time) ([]*message.Message, error) {
+	f.m.RLock()
+	defer f.m.RUnlock()
+
+	var locs []messageLocation
+
+	for _, loc := range f.messages[Key(peer)] {
+		if InRange(loc.date, from, to) {
+			locs = append(locs, loc)
+		}
+	}
+
+	sort.Slice(locs, func(i, j int) bool {
+		if !locs[i].date.Equal(locs[j].date) {
+			return locs[i].date.After(locs[j].date)
+		}
+		return locs[i].id > locs[j].id
+	})
+
+	messages := make([]*message.Message, 0, len(locs))
+
+	for _, loc := range locs {
+		var m message.Message
+		if err := f.read(loc.location, &m); err != nil {
+			return nil, err
+		}
+		if views, ok := f.last[snapshotKey{Key(peer), m.ID}]; ok {
+			m.Views = views
+		}
+		messages = append(messages, &m)
+	}
+
+	return messages, nil
+}
+
+// Снимки за период (от старых к новым)
+func (f *File) Snapshots(peer string, from, to time.Time) ([]Snapshot, error) {
+	f.m.RLock()
+	defer f.m.RUnlock()
+
+	var locs []snapshotLocation
+
+	for _, loc := range f.snapshots[Key(peer)] {
+		if InRange(loc.time, from, to) {
+			locs = append(locs, loc)
+		}
+	}
+
+	sort.SliceStable(locs, func(i, j int) bool {
+		return locs[i].time.Before(locs[j].time)
+	})
+
+	snapshots := make([]Snapshot, 0, len(locs))
+
+	for _, loc := range locs {
+		var s Snapshot
+		if err := f.read(loc.location, &s); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	return snapshots, nil
+}
+
+// Каналы в хранилище (по порядку)
+func (f *File) Peers() []string {
+	f.m.RLock()
+	defer f.m.RUnlock()
+
+	peers := make([]string, 0, len(f.channels))
+	for peer := range f.channels {
+		peers = append(peers, peer)
+	}
+
+	sort.Strings(peers)
+
+	return peers
+}
+
+// Число пропущенных поврежденных записей (при открытии)
+func (f *File) Skipped() int {
+	f.m.RLock()
+	defer f.m.RUnlock()
+
+	return f.skipped
+}
+
+// Закрытие хранилища (с записью на диск)
+func (f *File) Close() error {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if f.isClosed {
+		return nil
+	}
+	f.isClosed = true
+
+	var errs []error
+
+	if file, ok := f.files[f.active]; ok {
+		errs = append(errs, file.Sync())
+	}
+	for _, file := range f.files {
+		errs = append(errs, file.Close())
+	}
+
+	return errors.Join(errs...)
+}
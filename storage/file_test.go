@@ -0,0 +1,294 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"statosphere/parser/channel"
+	"statosphere/parser/message"
+	"statosphere/parser/value"
+)
+
+// Начало отсчета времени в тестах
+var base = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// Сообщения с заданными ID (дата - через час после предыдущего, просмотры - ID*10)
+func testMessages(ids ...uint) []*message.Message {
+	messages := make([]*message.Message, 0, len(ids))
+	for _, id := range ids {
+		messages = append(messages, &message.Message{
+			ID:          id,
+			MessageHtml: "Сообщение",
+			Views:       value.Value{Exact: id * 10},
+			Date:        base.Add(time.Duration(id) * time.Hour),
+		})
+	}
+	return messages
+}
+
+// ID сообщений
+func idsOf(messages []*message.Message) []uint {
+	ids := make([]uint, 0, len(messages))
+	for _, m := range messages {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+// Размер сегментов
+func segmentSizes(t *testing.T, dir string) []int64 {
+	names, _ := filepath.Glob(filepath.Join(dir, "segment-*.log"))
+
+	sizes := make([]int64, 0, len(names))
+	for _, name := range names {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("Получена ошибка: %v", err)
+		}
+		sizes = append(sizes, info.Size())
+	}
+	return sizes
+}
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+
+	f, err := OpenFile(dir, 0)
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	c := &channel.Channel{Peer: "@CodeCamp", Link: "https://t.me/codecamp", Title: "Канал", Participants: value.Value{Exact: 100},
+		IsStale: true, Age: 30, Messages: testMessages(1)}
+
+	if err := f.UpsertChannel(c); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	if err := f.UpsertMessages("@codecamp", testMessages(3, 2, 1)); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	// Измененное сообщение заменяет прежнее
+	edited := testMessages(2)
+	edited[0].MessageHtml, edited[0].IsEdited = "Исправлено", true
+
+	if err := f.UpsertMessages("@codecamp", edited); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	// Удаленное сообщение исключается из выборки (в том числе после повторного открытия)
+	if err := f.UpsertMessages("@codecamp", testMessages(5)); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	if err := f.RemoveMessages("@codecamp", []uint{5, 9}); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	// Неизмененные данные и отметки неизвестных сообщений повторно не записываются
+	sizes := segmentSizes(t, dir)
+
+	// Просмотры хранятся только в снимках - их изменение не записывает сообщение заново
+	viewed := testMessages(3, 1)
+	viewed[0].Views = value.Value{Exact: 1000}
+
+	f.UpsertChannel(c)
+	f.UpsertMessages("@codecamp", viewed)
+	f.RemoveMessages("@codecamp", []uint{5})
+
+	if result := segmentSizes(t, dir); !reflect.DeepEqual(result, sizes) {
+		t.Errorf("Получен размер: %v, ожидается: %v", result, sizes)
+	}
+
+	check := func(t *testing.T, f *File) {
+		result, ok, err := f.Channel("@codecamp")
+		if err != nil || !ok {
+			t.Fatalf("Канал не получен: %v", err)
+		}
+		if result.Title != "Канал" || result.Participants.Exact != 100 || result.Messages != nil || result.IsStale || result.Age != 0 {
+			t.Errorf("Получено значение: %+v", result)
+		}
+
+		tests := []struct {
+			test   string
+			peer   string
+			from   time.Time
+			to     time.Time
+			result []uint
+		}{
+			{"All", "@codecamp", time.Time{}, time.Time{}, []uint{3, 2, 1}},
+			{"From", "@codecamp", base.Add(2 * time.Hour), time.Time{}, []uint{3, 2}},
+			{"To", "@codecamp", time.Time{}, base.Add(2 * time.Hour), []uint{1}},
+			{"Range", "@CODECAMP", base.Add(2 * time.Hour), base.Add(3 * time.Hour), []uint{2}},
+			{"Missing", "@missing", time.Time{}, time.Time{}, []uint{}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.test, func(t *testing.T) {
+				messages, err := f.Messages(tt.peer, tt.from, tt.to)
+				if err != nil {
+					t.Fatalf("Получена ошибка: %v", err)
+				}
+
+				if ids := idsOf(messages); !reflect.DeepEqual(ids, tt.result) {
+					t.Errorf("Получено значение: %v, ожидается: %v", ids, tt.result)
+				}
+			})
+		}
+
+		messages, _ := f.Messages("@codecamp", base.Add(2*time.Hour), base.Add(3*time.Hour))
+		if len(messages) != 1 || messages[0].MessageHtml != "Исправлено" || !messages[0].IsEdited {
+			t.Errorf("Получено значение: %+v", messages)
+		}
+
+		if peers := f.Peers(); !reflect.DeepEqual(peers, []string{"@codecamp"}) {
+			t.Errorf("Получено значение: %v", peers)
+		}
+	}
+
+	t.Run("Open", func(t *testing.T) { check(t, f) })
+
+	if err := f.Close(); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	if err := f.UpsertMessages("@codecamp", testMessages(4)); err != ErrClosed {
+		t.Errorf("Получена ошибка: %v, ожидается: %v", err, ErrClosed)
+	}
+
+	// После повторного открытия данные те же
+	reopened, err := OpenFile(dir, 0)
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	defer reopened.Close()
+
+	t.Run("Reopen", func(t *testing.T) { check(t, reopened) })
+}
+
+func TestSnapshots(t *testing.T) {
+	f, err := OpenFile(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	defer f.Close()
+
+	f.AppendSnapshots(
+		Snapshot{Peer: "@codecamp", Value: value.Value{Exact: 100}, Time: base},
+		Snapshot{Peer: "@codecamp", MessageID: 1, Value: value.Value{Exact: 10}, Time: base},
+		Snapshot{Peer: "@codecamp", Value: value.Value{Exact: 100}, Time: base.Add(time.Hour)}, // Повтор
+		Snapshot{Peer: "@codecamp", Value: value.Value{Exact: 110}, Time: base.Add(2 * time.Hour)},
+	)
+	f.AppendSnapshots(
+		Snapshot{Peer: "@codecamp", Value: value.Value{Exact: 110}, Time: base.Add(3 * time.Hour)}, // Повтор
+		Snapshot{Peer: "@codecamp", MessageID: 1, Value: value.Value{Exact: 20}, Time: base.Add(3 * time.Hour)},
+		Snapshot{Peer: "@other", Value: value.Value{Exact: 5}, Time: base},
+	)
+
+	if err := f.AppendSnapshots(Snapshot{Value: value.Value{Exact: 1}}); err == nil {
+		t.Errorf("Ошибка не получена")
+	}
+
+	tests := []struct {
+		test   string
+		from   time.Time
+		to     time.Time
+		result []uint
+	}{
+		{"All", time.Time{}, time.Time{}, []uint{100, 10, 110, 20}},
+		{"From", base.Add(time.Hour), time.Time{}, []uint{110, 20}},
+		{"To", time.Time{}, base.Add(time.Hour), []uint{100, 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			snapshots, err := f.Snapshots("@codecamp", tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("Получена ошибка: %v", err)
+			}
+
+			values := make([]uint, 0, len(snapshots))
+			for _, s := range snapshots {
+				values = append(values, s.Value.Value())
+			}
+
+			if !reflect.DeepEqual(values, tt.result) {
+				t.Errorf("Получено значение: %v, ожидается: %v", values, tt.result)
+			}
+		})
+	}
+}
+
+func TestSegments(t *testing.T) {
+	dir := t.TempDir()
+
+	f, err := OpenFile(dir, 1024)
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	for id := uint(1); id <= 20; id++ {
+		if err := f.UpsertMessages("@codecamp", testMessages(id)); err != nil {
+			t.Fatalf("Получена ошибка: %v", err)
+		}
+	}
+	f.Close()
+
+	sizes := segmentSizes(t, dir)
+	if len(sizes) < 3 {
+		t.Fatalf("Получено сегментов: %v", len(sizes))
+	}
+	for _, size := range sizes[:len(sizes)-1] {
+		if size > 1024 {
+			t.Errorf("Получен размер сегмента: %v", size)
+		}
+	}
+
+	// Недописанная запись в конце (сбой при записи) отрезается
+	last := filepath.Join(dir, "segment-"+fmt.Sprintf("%06d", len(sizes))+".log")
+	file, _ := os.OpenFile(last, os.O_WRONLY|os.O_APPEND, 0644)
+	file.WriteString(`0000abcd {"kind":"message","peer":"@codecamp","id":21`)
+	file.Close()
+
+	// Поврежденная запись в середине пропускается
+	first := filepath.Join(dir, "segment-000001.log")
+	data, _ := os.ReadFile(first)
+	data[20] ^= 0xff
+	os.WriteFile(first, data, 0644)
+
+	reopened, err := OpenFile(dir, 1024)
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	defer reopened.Close()
+
+	if reopened.Skipped() != 1 {
+		t.Errorf("Пропущено записей: %v, ожидается: %v", reopened.Skipped(), 1)
+	}
+
+	messages, err := reopened.Messages("@codecamp", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	if len(messages) != 19 || messages[0].ID != 20 {
+		t.Errorf("Получено сообщений: %v (%v)", len(messages), idsOf(messages))
+	}
+
+	// Запись после отрезанной записи читается
+	if err := reopened.UpsertMessages("@codecamp", testMessages(21)); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	reopened.Close()
+
+	again, err := OpenFile(dir, 1024)
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	defer again.Close()
+
+	if messages, _ := again.Messages("@codecamp", time.Time{}, time.Time{}); len(messages) != 20 || messages[0].ID != 21 || again.Skipped() != 1 {
+		t.Errorf("Получено сообщений: %v (%v)", len(messages), idsOf(messages))
+	}
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"strings"
+	"time"
+
+	"statosphere/parser/channel"
+	"statosphere/parser/message"
+	"statosphere/parser/value"
+)
+
+// Снимок показателя на момент времени (числа подписчиков канала или просмотров сообщения)
+type Snapshot struct {
+	Peer      string      `json:"peer"`
+	MessageID uint        `json:"messageId,omitempty"` // 0 - число подписчиков канала
+	Value     value.Value `json:"value"`
+	Time      time.Time   `json:"time"`
+}
+
+// Хранилище каналов, сообщений и снимков показателей
+type Store interface {
+	UpsertChannel(c *channel.Channel) error                               // Запись канала (без сообщений)
+	UpsertMessages(peer string, messages []*message.Message) error        // Запись сообщений (по каналу и ID сообщения)
+	RemoveMessages(peer string, ids []uint) error                         // Отметка сообщений удаленными
+	AppendSnapshots(snapshots ...Snapshot) error                          // Добавление снимков
+	Channel(peer string) (*channel.Channel, bool, error)                  // Канал (без сообщений)
+	Messages(peer string, from, to time.Time) ([]*message.Message, error) // Сообщения за период (от новых к старым)
+	Snapshots(peer string, from, to time.Time) ([]Snapshot, error)        // Снимки за период (от старых к новым)
+	Peers() []string                                                      // Каналы в хранилище
+	Close() error
+}
+
+// Приведение канала к ключу хранилища
+func Key(peer string) string {
+	return strings.ToLower(peer)
+}
+
+// Попадает ли время в период (нулевая граница - без ограничения, конец не включается)
+func InRange(t, from, to time.Time) bool {
+	return (from.IsZero() || !t.Before(from)) && (to.IsZero() || t.Before(to))
+}
+
+// Запись спарсенного канала с сообщениями и снимками показателей на заданное время
+func Save(s Store, c *channel.Channel, at time.Time) error {
+	if c.Title == "" || c.Peer == "" {
+		return nil
+	}
+
+	if err := s.UpsertChannel(c); err != nil {
+		return err
+	}
+
+	if err := s.UpsertMessages(c.Peer, c.Messages); err != nil {
+		return err
+	}
+
+	var snapshots []Snapshot
+
+	if c.Participants.Value() > 0 {
+		snapshots = append(snapshots, Snapshot{Peer: c.Peer, Value: c.Participants, Time: at})
+	}
+	for _, m := range c.Messages {
+		if m != nil && m.ID != 0 && m.Views.Value() > 0 {
+			snapshots = append(snapshots, Snapshot{Peer: c.Peer, MessageID: m.ID, Value: m.Views, Time: at})
+		}
+	}
+
+	return s.AppendSnapshots(snapshots...)
+}
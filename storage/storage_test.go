@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"statosphere/parser/channel"
+	"statosphere/parser/value"
+)
+
+func TestInRange(t *testing.T) {
+	tests := []struct {
+		test   string
+		from   time.Time
+		to     time.Time
+		result bool
+	}{
+		{"Unbounded", time.Time{}, time.Time{}, true},
+		{"From", base, time.Time{}, true},
+		{"FromAfter", base.Add(time.Second), time.Time{}, false},
+		{"To", time.Time{}, base, false},
+		{"ToAfter", time.Time{}, base.Add(time.Second), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			result := InRange(base, tt.from, tt.to)
+
+			if result != tt.result {
+				t.Errorf("Получено значение: %v, ожидается: %v", result, tt.result)
+			}
+		})
+	}
+}
+
+func TestSave(t *testing.T) {
+	f, err := OpenFile(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+	defer f.Close()
+
+	// Неспарсенный канал не записывается
+	if err := Save(f, &channel.Channel{Peer: "@empty"}, base); err != nil || len(f.Peers()) != 0 {
+		t.Errorf("Записан неспарсенный канал: %v", err)
+	}
+
+	c := &channel.Channel{Peer: "@codecamp", Title: "Канал", Participants: value.Value{Exact: 100}, Messages: testMessages(2, 1)}
+
+	if err := Save(f, c, base); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	sizes := segmentSizes(t, f.dir)
+
+	c.Participants = value.Value{Exact: 120}
+	c.Messages[0].Views = value.Value{Exact: 30}
+
+	if err := Save(f, c, base.Add(time.Hour)); err != nil {
+		t.Fatalf("Получена ошибка: %v", err)
+	}
+
+	// Просмотры - из последнего снимка
+	messages, _ := f.Messages("@codecamp", time.Time{}, time.Time{})
+	if len(messages) != 2 || messages[0].Views.Exact != 30 || messages[1].Views.Exact != 10 {
+		t.Errorf("Получено значение: %+v", messages)
+	}
+
+	// Дописаны только канал с новым числом подписчиков и два снимка, без повторной записи сообщения
+	data := *c
+	data.Messages = nil
+	raw, _ := json.Marshal(data)
+	line, _, _ := encodeRecord(record{Kind: kindChannel, Peer: c.Peer, Data: raw})
+	size := int64(len(line))
+
+	for _, s := range []Snapshot{{Peer: "@codecamp", Value: c.Participants, Time: base.Add(time.Hour)},
+		{Peer: "@codecamp", MessageID: 2, Value: c.Messages[0].Views, Time: base.Add(time.Hour)}} {
+		raw, _ := json.Marshal(s)
+		line, _, _ := encodeRecord(record{Kind: kindSnapshot, Peer: s.Peer, ID: s.MessageID, Time: s.Time, Data: raw})
+		size += int64(len(line))
+	}
+
+	if result := segmentSizes(t, f.dir); result[0] != sizes[0]+size {
+		t.Errorf("Получен размер: %v, ожидается: %v", result[0], sizes[0]+size)
+	}
+
+	// Подписчики (100, 120), просмотры сообщения 2 (20, 30) и сообщения 1 (10, без повтора)
+	snapshots, _ := f.Snapshots("@codecamp", time.Time{}, time.Time{})
+	if len(snapshots) != 5 {
+		t.Errorf("Получено снимков: %v, ожидается: %v (%+v)", len(snapshots), 5, snapshots)
+	}
+}